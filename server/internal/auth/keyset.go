@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultKeyID is the kid used when the keyset is built from a single jwt.jwtSecret
+const DefaultKeyID = "default"
+
+// Key is one HMAC signing secret identified by its kid header
+type Key struct {
+	ID     string `json:"kid"`
+	Secret string `json:"secret"`
+}
+
+// Keyset holds every key that may verify a token; only ActiveKeyID signs new tokens.
+// Rotating means adding a key, switching active_kid, and dropping the old key once
+// all tokens signed with it have expired.
+type Keyset struct {
+	ActiveKeyID string `json:"active_kid"`
+	Keys        []Key  `json:"keys"`
+}
+
+// SingleKeyKeyset wraps a plain secret (jwt.jwtSecret) as a one-key keyset
+func SingleKeyKeyset(secret string) *Keyset {
+	return &Keyset{ActiveKeyID: DefaultKeyID, Keys: []Key{{ID: DefaultKeyID, Secret: secret}}}
+}
+
+// LoadKeysetFile reads a JSON keyset, e.g.
+//
+//	{"active_kid": "2025-10", "keys": [{"kid": "2025-10", "secret": "..."}, {"kid": "2025-04", "secret": "..."}]}
+func LoadKeysetFile(path string) (*Keyset, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ks Keyset
+	if err := json.Unmarshal(b, &ks); err != nil {
+		return nil, fmt.Errorf("parse keyset %s: %w", path, err)
+	}
+	if err := ks.Validate(); err != nil {
+		return nil, fmt.Errorf("keyset %s: %w", path, err)
+	}
+	return &ks, nil
+}
+
+// Validate checks that kids are unique, secrets are non-empty and the active key exists
+func (ks *Keyset) Validate() error {
+	if len(ks.Keys) == 0 {
+		return errors.New("no keys configured")
+	}
+	seen := map[string]bool{}
+	for _, k := range ks.Keys {
+		if k.ID == "" {
+			return errors.New("key without kid")
+		}
+		if k.Secret == "" {
+			return fmt.Errorf("key %q has an empty secret", k.ID)
+		}
+		if seen[k.ID] {
+			return fmt.Errorf("duplicate kid %q", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	if !seen[ks.ActiveKeyID] {
+		return fmt.Errorf("active_kid %q is not in keys", ks.ActiveKeyID)
+	}
+	return nil
+}
+
+func (ks *Keyset) lookup(kid string) ([]byte, bool) {
+	for _, k := range ks.Keys {
+		if k.ID == kid {
+			return []byte(k.Secret), true
+		}
+	}
+	return nil, false
+}
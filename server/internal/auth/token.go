@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Defaults applied by NewTokenService for empty config fields
+const (
+	DefaultIssuer    = "dishdive"
+	DefaultAudience  = "dishdive-app"
+	DefaultAccessTTL = 15 * time.Minute
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// TokenService issues and verifies access tokens; it is the only place that touches JWTs
+type TokenService interface {
+	Issue(userID uint, sessionID string) (token string, expiresAt time.Time, err error)
+	Verify(token string) (*Claims, error)
+}
+
+// Claims are the verified contents of an access token
+type Claims struct {
+	UserID    uint
+	SessionID string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type Config struct {
+	Keyset    *Keyset
+	Issuer    string
+	Audience  string
+	AccessTTL time.Duration
+}
+
+// accessClaims is the wire format: user ID in "sub", session (refresh-token family) in "sid"
+type accessClaims struct {
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+type jwtTokenService struct {
+	keys      *Keyset
+	issuer    string
+	audience  string
+	accessTTL time.Duration
+}
+
+func NewTokenService(cfg Config) (TokenService, error) {
+	if cfg.Keyset == nil {
+		return nil, errors.New("auth: keyset is required")
+	}
+	if err := cfg.Keyset.Validate(); err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = DefaultIssuer
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = DefaultAudience
+	}
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = DefaultAccessTTL
+	}
+	return &jwtTokenService{
+		keys:      cfg.Keyset,
+		issuer:    cfg.Issuer,
+		audience:  cfg.Audience,
+		accessTTL: cfg.AccessTTL,
+	}, nil
+}
+
+func (s *jwtTokenService) Issue(userID uint, sessionID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.accessTTL)
+	claims := accessClaims{
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  jwt.ClaimStrings{s.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = s.keys.ActiveKeyID
+	secret, _ := s.keys.lookup(s.keys.ActiveKeyID)
+	signed, err := token.SignedString(secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Verify checks signature (by kid), exp/nbf/iat, issuer and audience, and returns the claims.
+// A leading "Bearer " is tolerated so handlers can pass the Authorization header as is.
+func (s *jwtTokenService) Verify(tokenStr string) (*Claims, error) {
+	tokenStr = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tokenStr), "Bearer "))
+	if tokenStr == "" {
+		return nil, ErrInvalidToken
+	}
+
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("missing kid header")
+		}
+		secret, ok := s.keys.lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, ErrExpiredToken
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if !claims.VerifyExpiresAt(time.Now(), true) {
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if !claims.VerifyIssuer(s.issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if !claims.VerifyAudience(s.audience, true) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return nil, fmt.Errorf("%w: invalid subject", ErrInvalidToken)
+	}
+
+	out := &Claims{UserID: uint(userID), SessionID: claims.SessionID}
+	if claims.IssuedAt != nil {
+		out.IssuedAt = claims.IssuedAt.Time
+	}
+	out.ExpiresAt = claims.ExpiresAt.Time
+	return out, nil
+}
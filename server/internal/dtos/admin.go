@@ -0,0 +1,84 @@
+package dtos
+
+// Request bodies for the /admin routes. Update requests use pointers so omitted
+// fields are left unchanged.
+
+type AdminRestaurantRequest struct {
+	ResName        *string `json:"res_name"`
+	ResCuisine     *string `json:"res_cuisine,omitempty"`
+	ResRestriction *string `json:"res_restriction,omitempty"`
+	MenuSize       *int    `json:"menu_size,omitempty"`
+	ImageTag       *string `json:"image_tag,omitempty"`
+}
+
+type AdminKeywordRequest struct {
+	Keyword   *string `json:"keyword"`
+	Category  *string `json:"category"`
+	Sentiment *string `json:"sentiment,omitempty"`
+}
+
+type AdminDishAliasRequest struct {
+	DishID  uint   `json:"dish_id"`
+	AltName string `json:"alt_name"`
+}
+
+type AdminKeywordAliasRequest struct {
+	KeywordID uint   `json:"keyword_id"`
+	AltWord   string `json:"alt_word"`
+}
+
+// AdminResolveDishCandidateRequest maps a candidate dish name to dish_id (adding the name as an
+// alias), adds it as a new dish when dish_id is omitted, or dismisses it
+type AdminResolveDishCandidateRequest struct {
+	ResID   uint   `json:"res_id"`
+	Name    string `json:"name"`
+	DishID  *uint  `json:"dish_id,omitempty"`
+	Dismiss bool   `json:"dismiss,omitempty"`
+}
+
+// AdminSetCurrentReviewExtractRequest picks which stored extraction run of a user review is current
+type AdminSetCurrentReviewExtractRequest struct {
+	ReviewID  uint `json:"review_id"`
+	ExtractID uint `json:"extract_id"`
+}
+
+type AdminWhitelistRequest struct {
+	ResName string `json:"res_name"`
+}
+
+type AdminSetUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// AdminRecategorizeResponse lists the keywords RecategorizeKeywords moved, or would move when DryRun
+type AdminRecategorizeResponse struct {
+	DryRun  bool                         `json:"dry_run"`
+	Scanned int                          `json:"scanned"`
+	Changes []AdminKeywordCategoryChange `json:"changes"`
+}
+
+type AdminKeywordCategoryChange struct {
+	KeywordID  uint   `json:"keyword_id"`
+	Keyword    string `json:"keyword"`
+	Sentiment  string `json:"sentiment,omitempty"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	MergedInto *uint  `json:"merged_into,omitempty"` // set when an identical keyword already existed there
+}
+
+// AdminResolveDishCandidateResponse reports a resolved candidate and the reviews normalized again
+type AdminResolveDishCandidateResponse struct {
+	Status       string `json:"status"`
+	DishID       uint   `json:"dish_id,omitempty"`
+	AliasID      uint   `json:"alias_id,omitempty"`
+	Mentions     int    `json:"mentions"`
+	Renormalized int    `json:"renormalized"`
+	Failed       int    `json:"failed,omitempty"`
+}
+
+// AdminSetCurrentReviewExtractResponse reports the new current run and the keywords it attached
+type AdminSetCurrentReviewExtractResponse struct {
+	ReviewID         uint `json:"review_id"`
+	ExtractID        uint `json:"extract_id"`
+	KeywordsAttached int  `json:"keywords_attached"`
+}
@@ -0,0 +1,16 @@
+package dtos
+
+// ErrorResponse is the envelope returned for authentication, authorization and validation failures
+type ErrorResponse struct {
+	Status  int          `json:"status"`
+	Error   string       `json:"error"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes why one request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
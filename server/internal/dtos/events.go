@@ -0,0 +1,25 @@
+package dtos
+
+import "time"
+
+// ReviewEventData is the payload of review.* events on /ReviewEvents
+type ReviewEventData struct {
+	ReviewID         uint       `json:"review_id"`
+	DishID           uint       `json:"dish_id"`
+	Attempt          int        `json:"attempt,omitempty"`
+	Model            string     `json:"model,omitempty"`
+	UsedFallback     bool       `json:"used_fallback,omitempty"`
+	KeywordsAttached int        `json:"keywords_attached,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	WillRetry        bool       `json:"will_retry,omitempty"`
+	NextRunAt        *time.Time `json:"next_run_at,omitempty"`
+}
+
+// DishScoreEventData is the payload of dish.score_changed events
+type DishScoreEventData struct {
+	DishID        uint    `json:"dish_id"`
+	ReviewID      uint    `json:"review_id"`
+	PositiveScore int     `json:"positive_score"`
+	NegativeScore int     `json:"negative_score"`
+	TotalScore    float64 `json:"total_score"`
+}
@@ -75,6 +75,6 @@ type DishDetailResponse struct {
 	TotalReviews    int                 `json:"total_reviews"`
 	Cuisine         *string             `json:"cuisine,omitempty"`
 	ProminentFlavor *string             `json:"prominent_flavor,omitempty"`
-	TopKeywords     map[string][]string `json:"top_keywords"` // e.g. {"flavor": [...], "cost": [...], "general": [...]}
+	TopKeywords     map[string][]string `json:"top_keywords"` // one list per keyword category plus "general", e.g. {"flavor": [...], "texture": [...], "general": [...]}
 	IsFavorite      bool                `json:"is_favorite"`
 }
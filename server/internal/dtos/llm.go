@@ -0,0 +1,74 @@
+package dtos
+
+import "time"
+
+// LLMCacheStatsResponse reports extraction cache counters since the server started
+type LLMCacheStatsResponse struct {
+	MemoryHits  uint64  `json:"memory_hits"`
+	StoreHits   uint64  `json:"store_hits"`
+	Misses      uint64  `json:"misses"`
+	HitRatio    float64 `json:"hit_ratio"`
+	Writes      uint64  `json:"writes"`
+	StoreErrors uint64  `json:"store_errors"`
+	Entries     int     `json:"entries"`
+	Capacity    int     `json:"capacity"`
+	Enabled     bool    `json:"enabled"`
+}
+
+// LLMModelTotals are one model's call counters since the server started
+type LLMModelTotals struct {
+	Model            string  `json:"model"`
+	Calls            uint64  `json:"calls"`
+	Errors           uint64  `json:"errors"`
+	SchemaCalls      uint64  `json:"schema_calls"` // answered under a JSON schema
+	Fallbacks        uint64  `json:"fallbacks"`    // answers replaced by rule-based extraction
+	PromptTokens     uint64  `json:"prompt_tokens"`
+	CompletionTokens uint64  `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	AvgLatencyMS     float64 `json:"avg_latency_ms"`
+}
+
+// LLMUsageDay aggregates one model's calls on one day (server time zone)
+type LLMUsageDay struct {
+	Day              string  `json:"day"` // YYYY-MM-DD
+	Model            string  `json:"model"`
+	Calls            int64   `json:"calls"`
+	Errors           int64   `json:"errors"`
+	SchemaCalls      int64   `json:"schema_calls"`
+	Fallbacks        int64   `json:"fallbacks"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	AvgLatencyMS     float64 `json:"avg_latency_ms"`
+}
+
+// LLMUsageResponse combines today's budget state, in-process totals and stored daily history
+type LLMUsageResponse struct {
+	SpentTodayUSD  float64          `json:"spent_today_usd"`
+	DailyBudgetUSD float64          `json:"daily_budget_usd"` // 0 means no budget
+	QueuePaused    bool             `json:"queue_paused"`
+	SinceStart     []LLMModelTotals `json:"since_start"`
+	Daily          []LLMUsageDay    `json:"daily,omitempty"`
+}
+
+// LLMCallResponse is one provider call made while processing a review
+type LLMCallResponse struct {
+	Model            string    `json:"model"`
+	Outcome          string    `json:"outcome"`
+	ResponseFormat   string    `json:"response_format,omitempty"`
+	UsedFallback     bool      `json:"used_fallback"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	LatencyMS        int64     `json:"latency_ms"`
+	CostUSD          float64   `json:"cost_usd"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ReviewLLMUsageResponse lists a review's provider calls with their totals
+type ReviewLLMUsageResponse struct {
+	ReviewID         uint              `json:"review_id"`
+	Calls            []LLMCallResponse `json:"calls"`
+	PromptTokens     int               `json:"prompt_tokens"`
+	CompletionTokens int               `json:"completion_tokens"`
+	CostUSD          float64           `json:"cost_usd"`
+}
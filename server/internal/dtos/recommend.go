@@ -1,5 +1,10 @@
 package dtos
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Unified Settings DTOs
 type KeywordSettingResponse struct {
 	KeywordID       uint    `json:"keyword_id"`
@@ -62,6 +67,76 @@ type ReviewExtractStatusResponse struct {
 	Found      bool   `json:"found"`
 }
 
+// ReviewProcessingStatusResponse combines the review job and extraction details of a user review.
+// Status is one of pending, running, retrying, failed, extracted or normalized.
+type ReviewProcessingStatusResponse struct {
+	ReviewID     uint   `json:"review_id"`
+	Status       string `json:"status"`
+	ExtractFound bool   `json:"extract_found"`
+	Normalized   bool   `json:"normalized"`
+
+	// Job progress
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+
+	// Stage timestamps
+	QueuedAt     *time.Time `json:"queued_at,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"` // latest attempt
+	NextRunAt    *time.Time `json:"next_run_at,omitempty"`
+	ExtractedAt  *time.Time `json:"extracted_at,omitempty"`
+	NormalizedAt *time.Time `json:"normalized_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+
+	// Extraction details
+	PromptVersion      string          `json:"prompt_version,omitempty"`
+	Model              string          `json:"model,omitempty"`
+	UsedFallback       bool            `json:"used_fallback"`
+	KeywordsAttached   int             `json:"keywords_attached"`
+	ValidationWarnings json.RawMessage `json:"validation_warnings,omitempty"` // repairs made to the model answer
+	Reasked            bool            `json:"reasked"`
+}
+
+// ReviewExtractSummary describes one stored extraction run of a review
+type ReviewExtractSummary struct {
+	ExtractID        uint       `json:"extract_id"`
+	IsCurrent        bool       `json:"is_current"`
+	PromptVersion    string     `json:"prompt_version,omitempty"`
+	PromptHash       string     `json:"prompt_hash,omitempty"`
+	Model            string     `json:"model,omitempty"`
+	UsedFallback     bool       `json:"used_fallback"`
+	ExtractedAt      *time.Time `json:"extracted_at,omitempty"`
+	NormalizedAt     *time.Time `json:"normalized_at,omitempty"`
+	KeywordsAttached int        `json:"keywords_attached"`
+	Items            int        `json:"items"`
+
+	ValidationWarnings json.RawMessage `json:"validation_warnings,omitempty"`
+	Reasked            bool            `json:"reasked"`
+}
+
+// ReviewExtractDiffResponse compares two extraction runs of the same review, dish by dish
+type ReviewExtractDiffResponse struct {
+	ReviewID        uint                 `json:"review_id"`
+	From            ReviewExtractSummary `json:"from"`
+	To              ReviewExtractSummary `json:"to"`
+	AddedDishes     []string             `json:"added_dishes"`
+	RemovedDishes   []string             `json:"removed_dishes"`
+	ChangedDishes   []DishExtractDiff    `json:"changed_dishes"`
+	UnchangedDishes []string             `json:"unchanged_dishes"`
+}
+
+// DishExtractDiff lists what changed for a dish found in both runs
+type DishExtractDiff struct {
+	Dish            string   `json:"dish"`
+	CuisineFrom     string   `json:"cuisine_from,omitempty"`
+	CuisineTo       string   `json:"cuisine_to,omitempty"`
+	RestrictionFrom string   `json:"restriction_from,omitempty"`
+	RestrictionTo   string   `json:"restriction_to,omitempty"`
+	PositiveAdded   []string `json:"positive_added,omitempty"`
+	PositiveRemoved []string `json:"positive_removed,omitempty"`
+	NegativeAdded   []string `json:"negative_added,omitempty"`
+	NegativeRemoved []string `json:"negative_removed,omitempty"`
+}
+
 // Compact English group status for easy UI binding
 type ENGroupStatusResponse struct {
 	FlavorEN map[string]bool `json:"flavor_en"`
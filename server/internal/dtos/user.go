@@ -1,34 +1,33 @@
 package dtos
 
-type UserDataResponse struct {
-	UserID       uint    `json:"user_id"`
-	Username     *string `json:"username"`
-	ImageLink    *string `json:"image_link,omitempty"`
-	PasswordHash string  `json:"password_hash"`
-}
+import "time"
 
-type UserByUserIdDataResponse struct {
-	UserID       uint    `json:"user_id"`
-	Username     *string `json:"username"`
-	ImageLink    *string `json:"image_link,omitempty"`
-	PasswordHash string  `json:"password_hash"`
+// PublicUser is the user projection handlers return to regular clients.
+// It must never gain credential fields; see middleware.GuardSensitiveFields.
+type PublicUser struct {
+	UserID    uint    `json:"user_id"`
+	Username  *string `json:"username"`
+	ImageLink *string `json:"image_link,omitempty"`
 }
 
-type UserByTokenDataResponse struct {
-	UserID       uint    `json:"user_id"`
-	Username     *string `json:"username"`
-	ImageLink    *string `json:"image_link,omitempty"`
-	PasswordHash string  `json:"password_hash"`
+// AdminUserView is the admin-only user listing row
+type AdminUserView struct {
+	UserID         uint    `json:"user_id"`
+	Username       *string `json:"username"`
+	ImageLink      *string `json:"image_link,omitempty"`
+	Role           string  `json:"role"`
+	ActiveSessions int64   `json:"active_sessions"`
 }
 
+type UserDataResponse = AdminUserView
+
+type UserByUserIdDataResponse = PublicUser
+
+type UserByTokenDataResponse = PublicUser
+
 //////////////////////////////////////////////////////////////////////////////
 
-type CurrentUserResponse struct {
-	UserID       uint    `json:"user_id"`
-	Username     *string `json:"username"`
-	ImageLink    *string `json:"image_link,omitempty"`
-	PasswordHash string  `json:"password_hash"`
-}
+type CurrentUserResponse = PublicUser
 
 type ProfileOfCurrentUserByUserIdResponse struct {
 	UserID    uint    `json:"user_id"`
@@ -66,7 +65,36 @@ type UserResponse struct {
 }
 
 type LoginResponse struct {
-	UserID   uint    `json:"user_id"`
-	Username *string  `json:"user_name"`
-	Token    *string `json:"token,omitempty"`
+	UserID           uint       `json:"user_id"`
+	Username         *string    `json:"user_name"`
+	Token            *string    `json:"token,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	RefreshToken     *string    `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type RequestPasswordResetRequest struct {
+	Username *string `json:"user_name"`
+}
+
+type ResetPasswordRequest struct {
+	ResetToken  string `json:"reset_token"`
+	NewPassword string `json:"new_password"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
 }
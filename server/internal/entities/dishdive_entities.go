@@ -1,16 +1,55 @@
 package entities
 
+import "time"
+
 type User struct {
 	UserID       uint    `gorm:"column:user_id;primaryKey;autoIncrement" json:"user_id"`
-	Username     *string `gorm:"column:user_name;size:100;not null" json:"user_name"`
+	Username     *string `gorm:"column:user_name;size:100;not null;uniqueIndex:idx_users_user_name" json:"user_name"`
 	ImageLink    *string `gorm:"column:image_link;size:255" json:"image_link,omitempty"`
-	PasswordHash string  `gorm:"column:password_hash;size:255;not null" json:"password_hash"`
+	PasswordHash string  `gorm:"column:password_hash;size:255;not null" json:"-"`
+	Role         string  `gorm:"column:role;size:20;not null;default:user" json:"role"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// RefreshToken is one link in a rotating refresh-token chain; FamilyID identifies the login session
+type RefreshToken struct {
+	TokenID      uint       `gorm:"column:token_id;primaryKey;autoIncrement" json:"token_id"`
+	UserID       uint       `gorm:"column:user_id;not null;index" json:"user_id"`
+	FamilyID     string     `gorm:"column:family_id;size:64;not null;index" json:"family_id"`
+	TokenHash    string     `gorm:"column:token_hash;size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt    time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	RevokedAt    *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	ReplacedByID *uint      `gorm:"column:replaced_by_id" json:"replaced_by_id,omitempty"`
+	CreatedAt    time.Time  `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// PasswordResetToken is a single-use, expiring reset credential; only its hash is stored
+type PasswordResetToken struct {
+	ResetID   uint       `gorm:"column:reset_id;primaryKey;autoIncrement" json:"reset_id"`
+	UserID    uint       `gorm:"column:user_id;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"column:token_hash;size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
 type Restaurant struct {
 	ResID          uint    `gorm:"column:res_id;primaryKey;autoIncrement" json:"res_id"`
 	ResName        string  `gorm:"column:res_name;size:255;not null;unique" json:"res_name"`
@@ -24,6 +63,17 @@ func (Restaurant) TableName() string {
 	return "restaurants"
 }
 
+// RestaurantWhitelistEntry marks a restaurant name as visible in listings and search
+type RestaurantWhitelistEntry struct {
+	WLID      uint      `gorm:"column:wl_id;primaryKey;autoIncrement" json:"wl_id"`
+	ResName   string    `gorm:"column:res_name;size:255;not null;uniqueIndex" json:"res_name"`
+	CreatedAt time.Time `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (RestaurantWhitelistEntry) TableName() string {
+	return "restaurant_whitelist"
+}
+
 type RestaurantLocation struct {
 	RLID         uint    `gorm:"column:rl_id;primaryKey;autoIncrement" json:"rl_id"`
 	ResID        uint    `gorm:"column:res_id;not null;index" json:"res_id"`
@@ -46,6 +96,10 @@ type Dish struct {
 	PositiveScore int     `gorm:"column:positive_score;not null" json:"positive_score"`
 	NegativeScore int     `gorm:"column:negative_score;not null" json:"negative_score"`
 	TotalScore    float64 `gorm:"column:total_score;not null" json:"total_score"`
+	// Positive and negative review counts weighted by keyword intensity (see
+	// RecomputeScoresAndRestaurants); the recommendation score uses these, the counts stay counts
+	WeightedPositive float64 `gorm:"column:weighted_positive;not null;default:0" json:"weighted_positive"`
+	WeightedNegative float64 `gorm:"column:weighted_negative;not null;default:0" json:"weighted_negative"`
 }
 
 func (Dish) TableName() string {
@@ -145,11 +199,27 @@ func (WebReview) TableName() string {
 	return "web_reviews"
 }
 
+// ReviewExtract is one extraction run of a review. Every run is kept; IsCurrent marks the one
+// normalization and the status endpoints use (at most one per source).
 type ReviewExtract struct {
 	ExtractID   uint   `gorm:"column:rev_ext_id;primaryKey;autoIncrement" json:"extract_id"`
-	SourceID    uint   `gorm:"column:source_id;not null;index" json:"source_id"`
-	SourceType  string `gorm:"column:source_type;not null;index" json:"source_type"`
+	SourceID    uint   `gorm:"column:source_id;not null;index;uniqueIndex:idx_review_extracts_current,where:is_current" json:"source_id"`
+	SourceType  string `gorm:"column:source_type;not null;index;uniqueIndex:idx_review_extracts_current" json:"source_type"`
 	DataExtract string `gorm:"column:data_extract;type:json;not null" json:"data_extract"`
+	IsCurrent   bool   `gorm:"column:is_current;not null;default:false" json:"is_current"`
+
+	// Processing details reported by GetReviewProcessingStatus
+	PromptVersion    string     `gorm:"column:prompt_version;size:50" json:"prompt_version,omitempty"`
+	PromptHash       string     `gorm:"column:prompt_hash;size:64" json:"prompt_hash,omitempty"`
+	Model            string     `gorm:"column:model;size:100" json:"model,omitempty"`
+	UsedFallback     bool       `gorm:"column:used_fallback;not null;default:false" json:"used_fallback"`
+	ExtractedAt      *time.Time `gorm:"column:extracted_at" json:"extracted_at,omitempty"`
+	NormalizedAt     *time.Time `gorm:"column:normalized_at" json:"normalized_at,omitempty"`
+	KeywordsAttached int        `gorm:"column:keywords_attached;not null;default:0" json:"keywords_attached"`
+
+	// JSON array of llm.ValidationWarning; empty when the answer passed validation
+	ValidationWarnings string `gorm:"column:validation_warnings;type:text;not null;default:''" json:"validation_warnings,omitempty"`
+	Reasked            bool   `gorm:"column:reasked;not null;default:false" json:"reasked"`
 }
 
 func (ReviewExtract) TableName() string {
@@ -157,10 +227,11 @@ func (ReviewExtract) TableName() string {
 }
 
 type ReviewDish struct {
-	RDID     uint `gorm:"column:review_dish_id;primaryKey;autoIncrement" json:"review_dish_id"`
-	DishID   uint `gorm:"column:dish_id;not null;index" json:"dish_id"`
-	ResID    uint `gorm:"column:res_id;not null;index" json:"res_id"`
-	SourceID uint `gorm:"column:source_id;not null;index" json:"source_id"`
+	RDID       uint   `gorm:"column:review_dish_id;primaryKey;autoIncrement" json:"review_dish_id"`
+	DishID     uint   `gorm:"column:dish_id;not null;index" json:"dish_id"`
+	ResID      uint   `gorm:"column:res_id;not null;index" json:"res_id"`
+	SourceID   uint   `gorm:"column:source_id;not null;index" json:"source_id"`
+	SourceType string `gorm:"column:source_type;size:64;not null;default:web" json:"source_type"` // "user" or "web"; source ids of the two overlap
 }
 
 func (ReviewDish) TableName() string {
@@ -171,8 +242,152 @@ type ReviewDishKeyword struct {
 	RDKID     uint `gorm:"column:review_dish_keyword_id;primaryKey;autoIncrement" json:"review_dish_keyword_id"`
 	RDID      uint `gorm:"column:review_dish_id;not null;index" json:"review_dish_id"`
 	KeywordID uint `gorm:"column:keyword_id;not null;index" json:"keyword_id"`
+	// Polarity is -1 when the review negated or inverted the keyword ("ไม่เค็ม", "หวานไป")
+	Polarity int `gorm:"column:polarity;not null;default:1" json:"polarity"`
+	// Intensity weights the keyword in scoring: 0.5 mild ... 1 plain ... 2 extreme ("เค็มมากๆ")
+	Intensity float64 `gorm:"column:intensity;not null;default:1" json:"intensity"`
 }
 
 func (ReviewDishKeyword) TableName() string {
 	return "review_dish_keywords"
 }
+
+// Dish candidate states
+const (
+	CandidatePending   = "pending"
+	CandidateResolved  = "resolved" // mapped to an existing dish or added as a new one
+	CandidateDismissed = "dismissed"
+)
+
+// DishCandidate is an extracted dish name that matched no dish or alias at the review's
+// restaurant. One row per review and name; an admin maps it to a dish, adds it or dismisses it.
+type DishCandidate struct {
+	DCID       uint      `gorm:"column:dc_id;primaryKey;autoIncrement" json:"dc_id"`
+	ResID      uint      `gorm:"column:res_id;not null;uniqueIndex:idx_dish_candidates_mention,priority:1" json:"res_id"`
+	NameKey    string    `gorm:"column:name_key;size:255;not null;uniqueIndex:idx_dish_candidates_mention,priority:2" json:"name_key"` // normalize.DishKey of Name
+	SourceID   uint      `gorm:"column:source_id;not null;uniqueIndex:idx_dish_candidates_mention,priority:3" json:"source_id"`
+	SourceType string    `gorm:"column:source_type;size:20;not null;uniqueIndex:idx_dish_candidates_mention,priority:4" json:"source_type"`
+	Name       string    `gorm:"column:name;size:255;not null" json:"name"`
+	HintDishID uint      `gorm:"column:hint_dish_id;not null" json:"hint_dish_id"` // dish the review was submitted for
+	Status     string    `gorm:"column:status;size:20;not null;default:pending;index" json:"status"`
+	DishID     *uint     `gorm:"column:dish_id" json:"dish_id,omitempty"` // set once resolved
+	CreatedAt  time.Time `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (DishCandidate) TableName() string {
+	return "dish_candidates"
+}
+
+// AdminAuditLog records one catalog or user mutation made through the /admin routes
+type AdminAuditLog struct {
+	AuditID    uint      `gorm:"column:audit_id;primaryKey;autoIncrement" json:"audit_id"`
+	AdminID    uint      `gorm:"column:admin_id;not null;index" json:"admin_id"`
+	Action     string    `gorm:"column:action;size:50;not null" json:"action"`
+	TargetType string    `gorm:"column:target_type;size:50;not null;index" json:"target_type"`
+	TargetID   uint      `gorm:"column:target_id;index" json:"target_id"`
+	Payload    string    `gorm:"column:payload;type:text" json:"payload,omitempty"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;index" json:"created_at"`
+}
+
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
+
+// LoginFailure is the login lockout state of one account from one client IP (see
+// userService.Login), so wrong passwords sent from elsewhere cannot lock the owner out
+type LoginFailure struct {
+	UserID      uint       `gorm:"column:user_id;primaryKey" json:"user_id"`
+	IP          string     `gorm:"column:ip;size:64;primaryKey" json:"ip"`
+	FailedCount int        `gorm:"column:failed_count;not null;default:0" json:"failed_count"`
+	LockedUntil *time.Time `gorm:"column:locked_until" json:"locked_until,omitempty"`
+}
+
+func (LoginFailure) TableName() string {
+	return "login_failures"
+}
+
+// RateLimitBucket is the token-bucket state used by the Postgres rate limit store
+type RateLimitBucket struct {
+	BucketKey string    `gorm:"column:bucket_key;size:255;primaryKey" json:"bucket_key"`
+	Tokens    float64   `gorm:"column:tokens;not null" json:"tokens"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null;index" json:"updated_at"`
+}
+
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// LLMCacheEntry is the durable tier of the LLM extraction cache, keyed by llm.CacheKey
+type LLMCacheEntry struct {
+	CacheKey      string    `gorm:"column:cache_key;size:64;primaryKey" json:"cache_key"`
+	Model         string    `gorm:"column:model;size:100;not null" json:"model"`
+	PromptVersion string    `gorm:"column:prompt_version;size:50;not null;index" json:"prompt_version"`
+	Response      string    `gorm:"column:response;type:json;not null" json:"response"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (LLMCacheEntry) TableName() string {
+	return "llm_cache"
+}
+
+// DataMigration marks a one-off data repair as done (see migrate.RunOnce)
+type DataMigration struct {
+	Name      string    `gorm:"column:name;size:100;primaryKey" json:"name"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null" json:"applied_at"`
+}
+
+func (DataMigration) TableName() string {
+	return "data_migrations"
+}
+
+// LLMCall records one LLM provider call (including failed attempts) for usage and cost accounting
+type LLMCall struct {
+	CallID           uint      `gorm:"column:call_id;primaryKey;autoIncrement" json:"call_id"`
+	SourceID         uint      `gorm:"column:source_id;not null;default:0;index:idx_llm_calls_source" json:"source_id"`
+	SourceType       string    `gorm:"column:source_type;size:20;not null;default:'';index:idx_llm_calls_source" json:"source_type"`
+	Model            string    `gorm:"column:model;size:100;not null" json:"model"`
+	Outcome          string    `gorm:"column:outcome;size:20;not null" json:"outcome"`
+	ResponseFormat   string    `gorm:"column:response_format;size:20;not null;default:''" json:"response_format"`
+	UsedFallback     bool      `gorm:"column:used_fallback;not null;default:false" json:"used_fallback"`
+	PromptTokens     int       `gorm:"column:prompt_tokens;not null;default:0" json:"prompt_tokens"`
+	CompletionTokens int       `gorm:"column:completion_tokens;not null;default:0" json:"completion_tokens"`
+	LatencyMS        int64     `gorm:"column:latency_ms;not null;default:0" json:"latency_ms"`
+	CostUSD          float64   `gorm:"column:cost_usd;not null;default:0" json:"cost_usd"`
+	CreatedAt        time.Time `gorm:"column:created_at;not null;index" json:"created_at"`
+}
+
+func (LLMCall) TableName() string {
+	return "llm_calls"
+}
+
+// Review job states
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// ReviewJob is one durable unit of review processing (LLM extraction + normalization)
+type ReviewJob struct {
+	JobID      uint       `gorm:"column:job_id;primaryKey;autoIncrement" json:"job_id"`
+	SourceID   uint       `gorm:"column:source_id;not null;uniqueIndex:idx_review_jobs_source" json:"source_id"`
+	SourceType string     `gorm:"column:source_type;size:20;not null;uniqueIndex:idx_review_jobs_source" json:"source_type"`
+	UserID     uint       `gorm:"column:user_id;not null;default:0;index" json:"user_id"` // reviewer, receives the job's events
+	DishID     uint       `gorm:"column:dish_id;not null" json:"dish_id"`
+	ResID      uint       `gorm:"column:res_id;not null" json:"res_id"`
+	Status     string     `gorm:"column:status;size:20;not null;index:idx_review_jobs_claim,priority:1" json:"status"`
+	Attempts   int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	LastError  string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	NextRunAt  time.Time  `gorm:"column:next_run_at;not null;index:idx_review_jobs_claim,priority:2" json:"next_run_at"`
+	LockedAt   *time.Time `gorm:"column:locked_at" json:"locked_at,omitempty"`
+	LockedBy   string     `gorm:"column:locked_by;size:100;not null;default:''" json:"locked_by,omitempty"`
+	StartedAt  *time.Time `gorm:"column:started_at" json:"started_at,omitempty"` // start of the latest attempt
+	FinishedAt *time.Time `gorm:"column:finished_at" json:"finished_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"column:created_at;not null" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;not null" json:"updated_at"`
+}
+
+func (ReviewJob) TableName() string {
+	return "review_jobs"
+}
@@ -0,0 +1,150 @@
+// Package events is an in-process publish/subscribe bus for per-user pipeline events,
+// with a ring buffer so reconnecting clients can resume from Last-Event-ID.
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	ReviewExtractionStarted = "review.extraction_started"
+	ReviewExtracted         = "review.extracted"
+	ReviewNormalized        = "review.normalized"
+	ReviewFailed            = "review.failed"
+	DishScoreChanged        = "dish.score_changed"
+	// StreamResync tells a resuming client that events were missed and it should refetch state
+	StreamResync = "stream.resync"
+)
+
+const (
+	defaultBufferSize   = 1024
+	subscriberQueueSize = 64
+	maxStreamsPerUser   = 5
+)
+
+// ErrTooManyStreams is returned when a user already has maxStreamsPerUser open streams
+var ErrTooManyStreams = errors.New("too many open event streams")
+
+// Event is delivered to the subscribers of UserID. ID increases monotonically per process.
+type Event struct {
+	ID     uint64      `json:"id"`
+	UserID uint        `json:"-"`
+	Type   string      `json:"type"`
+	Time   time.Time   `json:"time"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// Subscription receives the live events of one user
+type Subscription struct {
+	userID uint
+	ch     chan Event
+	bus    *Bus
+	once   sync.Once
+}
+
+// Events is closed when the subscription is closed or falls too far behind
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s)
+}
+
+type Bus struct {
+	mu     sync.Mutex
+	nextID uint64
+	ring   []Event // ring[i%len] holds recent events in ID order
+	count  int
+	subs   map[uint]map[*Subscription]struct{}
+}
+
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &Bus{ring: make([]Event, bufferSize), subs: make(map[uint]map[*Subscription]struct{})}
+}
+
+// Publish assigns the event ID and time, keeps it for resuming clients and fans it out.
+// A nil bus or an event without a user is ignored.
+func (b *Bus) Publish(e Event) {
+	if b == nil || e.UserID == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	e.ID = b.nextID
+	e.Time = time.Now()
+	b.ring[int(e.ID%uint64(len(b.ring)))] = e
+	if b.count < len(b.ring) {
+		b.count++
+	}
+	for sub := range b.subs[e.UserID] {
+		select {
+		case sub.ch <- e:
+		default:
+			// Slow consumer: drop it; the client reconnects with Last-Event-ID and catches up
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Subscribe registers a live subscription for userID and returns the buffered events after
+// lastEventID. When lastEventID can no longer be served from the buffer (too old, or from a
+// previous process) the backlog starts with a StreamResync event.
+func (b *Bus) Subscribe(userID uint, lastEventID uint64) ([]Event, *Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.subs[userID]) >= maxStreamsPerUser {
+		return nil, nil, ErrTooManyStreams
+	}
+
+	var backlog []Event
+	if lastEventID > 0 {
+		oldest := b.nextID - uint64(b.count) + 1
+		if lastEventID > b.nextID || lastEventID+1 < oldest {
+			backlog = append(backlog, Event{Type: StreamResync, UserID: userID, Time: time.Now()})
+		}
+		start := lastEventID + 1
+		if start < oldest {
+			start = oldest
+		}
+		for id := start; id <= b.nextID && lastEventID <= b.nextID; id++ {
+			if e := b.ring[int(id%uint64(len(b.ring)))]; e.UserID == userID {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	sub := &Subscription{userID: userID, ch: make(chan Event, subscriberQueueSize), bus: b}
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+	return backlog, sub, nil
+}
+
+func (b *Bus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(sub)
+}
+
+func (b *Bus) removeLocked(sub *Subscription) {
+	if set, ok := b.subs[sub.userID]; ok {
+		if _, ok := set[sub]; ok {
+			delete(set, sub)
+			if len(set) == 0 {
+				delete(b.subs, sub.userID)
+			}
+		}
+	}
+	sub.once.Do(func() { close(sub.ch) })
+}
@@ -3,8 +3,8 @@ package extract
 import (
 	"context"
 	"encoding/json"
-	"time"
 
+	"github.com/bestchayapol/DishDive/internal/entities"
 	"github.com/bestchayapol/DishDive/internal/llm"
 	"github.com/bestchayapol/DishDive/internal/repository"
 )
@@ -20,18 +20,17 @@ type SingleReviewInput struct {
 }
 
 type Service struct {
-	LLM  *llm.Client
+	LLM  llm.Extractor
 	Repo repository.RecommendRepository
 }
 
-func NewService(llmClient *llm.Client, repo repository.RecommendRepository) *Service {
+func NewService(llmClient llm.Extractor, repo repository.RecommendRepository) *Service {
 	return &Service{LLM: llmClient, Repo: repo}
 }
 
+// Run extracts and stores one review. ctx should carry the job deadline, which also bounds LLM retries.
 func (s *Service) Run(ctx context.Context, in SingleReviewInput) error {
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
+	ctx = llm.WithSource(ctx, in.SourceType, in.ReviewID)
 	out, err := s.LLM.Extract(ctx, in.RestaurantName, in.ReviewText, in.HintDish)
 	if err != nil {
 		return err
@@ -45,5 +44,19 @@ func (s *Service) Run(ctx context.Context, in SingleReviewInput) error {
 	}
 	// Persist exactly what the normalizer (and previous Python pipeline) expects: an array
 	payload, _ := json.Marshal(out.Items)
-	return s.Repo.UpsertReviewExtract(uint(in.ReviewID), in.SourceType, string(payload))
+	rec := &entities.ReviewExtract{
+		SourceID:      in.ReviewID,
+		SourceType:    in.SourceType,
+		DataExtract:   string(payload),
+		PromptVersion: out.PromptVersion,
+		PromptHash:    out.PromptHash,
+		Model:         out.Model,
+		UsedFallback:  out.UsedFallback,
+		Reasked:       out.Reasked,
+	}
+	if len(out.Warnings) > 0 {
+		warnings, _ := json.Marshal(out.Warnings)
+		rec.ValidationWarnings = string(warnings)
+	}
+	return s.Repo.SaveReviewExtract(rec)
 }
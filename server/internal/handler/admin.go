@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/middleware"
+	"github.com/bestchayapol/DishDive/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminHandler struct {
+	adminService service.AdminService
+}
+
+func NewAdminHandler(adminService service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// paramID parses a positive numeric path parameter
+func paramID(c *fiber.Ctx, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Params(name), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid "+name)
+	}
+	return uint(id), nil
+}
+
+// adminID returns the authenticated admin; RequireAuth and RequireRole run before these handlers
+func adminID(c *fiber.Ctx) uint {
+	id, _ := middleware.UserID(c)
+	return id
+}
+
+// Restaurants
+
+func (h *AdminHandler) GetRestaurants(c *fiber.Ctx) error {
+	resp, err := h.adminService.GetRestaurants()
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) AddRestaurant(c *fiber.Ctx) error {
+	var req dtos.AdminRestaurantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.CreateRestaurant(adminID(c), req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *AdminHandler) UpdateRestaurant(c *fiber.Ctx) error {
+	resID, err := paramID(c, "resID")
+	if err != nil {
+		return err
+	}
+	var req dtos.AdminRestaurantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.UpdateRestaurant(adminID(c), resID, req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+// Locations
+
+func (h *AdminHandler) AddOrUpdateLocation(c *fiber.Ctx) error {
+	var req dtos.RestaurantLocationResponse
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.AddOrUpdateLocation(adminID(c), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) DeleteLocation(c *fiber.Ctx) error {
+	rlID, err := paramID(c, "rlID")
+	if err != nil {
+		return err
+	}
+	if err := h.adminService.DeleteLocation(adminID(c), rlID); err != nil {
+		return err
+	}
+	return c.JSON(map[string]bool{"success": true})
+}
+
+// Keywords
+
+func (h *AdminHandler) GetKeywords(c *fiber.Ctx) error {
+	resp, err := h.adminService.GetKeywords(c.Query("category"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) AddKeyword(c *fiber.Ctx) error {
+	var req dtos.AdminKeywordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.CreateKeyword(adminID(c), req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *AdminHandler) UpdateKeyword(c *fiber.Ctx) error {
+	keywordID, err := paramID(c, "keywordID")
+	if err != nil {
+		return err
+	}
+	var req dtos.AdminKeywordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.UpdateKeyword(adminID(c), keywordID, req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) RecategorizeKeywords(c *fiber.Ctx) error {
+	resp, err := h.adminService.RecategorizeKeywords(adminID(c), c.QueryBool("dry_run"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+// Aliases
+
+func (h *AdminHandler) GetDishAliases(c *fiber.Ctx) error {
+	dishID, err := paramID(c, "dishID")
+	if err != nil {
+		return err
+	}
+	resp, err := h.adminService.GetDishAliases(dishID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) AddDishAlias(c *fiber.Ctx) error {
+	var req dtos.AdminDishAliasRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.AddDishAlias(adminID(c), req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *AdminHandler) DeleteDishAlias(c *fiber.Ctx) error {
+	daID, err := paramID(c, "daID")
+	if err != nil {
+		return err
+	}
+	if err := h.adminService.DeleteDishAlias(adminID(c), daID); err != nil {
+		return err
+	}
+	return c.JSON(map[string]bool{"success": true})
+}
+
+func (h *AdminHandler) GetDishCandidates(c *fiber.Ctx) error {
+	resp, err := h.adminService.GetDishCandidates(c.Query("status"), uint(c.QueryInt("res_id", 0)))
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) ResolveDishCandidate(c *fiber.Ctx) error {
+	var req dtos.AdminResolveDishCandidateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.ResolveDishCandidate(c.UserContext(), adminID(c), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) SetCurrentReviewExtract(c *fiber.Ctx) error {
+	var req dtos.AdminSetCurrentReviewExtractRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.SetCurrentReviewExtract(c.UserContext(), adminID(c), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) GetKeywordAliases(c *fiber.Ctx) error {
+	keywordID, err := paramID(c, "keywordID")
+	if err != nil {
+		return err
+	}
+	resp, err := h.adminService.GetKeywordAliases(keywordID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) AddKeywordAlias(c *fiber.Ctx) error {
+	var req dtos.AdminKeywordAliasRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.AddKeywordAlias(adminID(c), req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *AdminHandler) DeleteKeywordAlias(c *fiber.Ctx) error {
+	kaID, err := paramID(c, "kaID")
+	if err != nil {
+		return err
+	}
+	if err := h.adminService.DeleteKeywordAlias(adminID(c), kaID); err != nil {
+		return err
+	}
+	return c.JSON(map[string]bool{"success": true})
+}
+
+// Whitelist
+
+func (h *AdminHandler) GetWhitelist(c *fiber.Ctx) error {
+	resp, err := h.adminService.GetWhitelist()
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *AdminHandler) AddToWhitelist(c *fiber.Ctx) error {
+	var req dtos.AdminWhitelistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.adminService.AddToWhitelist(adminID(c), req)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *AdminHandler) RemoveFromWhitelist(c *fiber.Ctx) error {
+	wlID, err := paramID(c, "wlID")
+	if err != nil {
+		return err
+	}
+	if err := h.adminService.RemoveFromWhitelist(adminID(c), wlID); err != nil {
+		return err
+	}
+	return c.JSON(map[string]bool{"success": true})
+}
+
+// Users and audit
+
+func (h *AdminHandler) SetUserRole(c *fiber.Ctx) error {
+	userID, err := paramID(c, "userID")
+	if err != nil {
+		return err
+	}
+	var req dtos.AdminSetUserRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	if err := h.adminService.SetUserRole(adminID(c), userID, req); err != nil {
+		return err
+	}
+	return c.JSON(map[string]bool{"success": true})
+}
+
+func (h *AdminHandler) GetAuditLogs(c *fiber.Ctx) error {
+	resp, err := h.adminService.GetAuditLogs(c.QueryInt("limit", 50), c.QueryInt("offset", 0))
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/ratelimit"
+	"github.com/bestchayapol/DishDive/internal/validate"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorHandler renders typed validation/conflict and rate limit errors as the JSON error envelope
+// and leaves every other error to fiber's default handler
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var ve *validate.Error
+	if errors.As(err, &ve) {
+		reason := "validation_failed"
+		if ve.Status == fiber.StatusConflict {
+			reason = "conflict"
+		}
+		return c.Status(ve.Status).JSON(dtos.ErrorResponse{
+			Status:  ve.Status,
+			Error:   reason,
+			Message: ve.Message,
+			Fields:  ve.Fields,
+		})
+	}
+	var limited *ratelimit.Error
+	if errors.As(err, &limited) {
+		reason := limited.Reason
+		if reason == "" {
+			reason = "rate_limited"
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(limited.RetryAfterSeconds()))
+		return c.Status(fiber.StatusTooManyRequests).JSON(dtos.ErrorResponse{
+			Status:  fiber.StatusTooManyRequests,
+			Error:   reason,
+			Message: limited.Message,
+		})
+	}
+	return fiber.DefaultErrorHandler(c, err)
+}
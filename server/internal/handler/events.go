@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/events"
+	"github.com/bestchayapol/DishDive/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// sseHeartbeat keeps proxies from closing idle streams and detects dropped clients
+const sseHeartbeat = 25 * time.Second
+
+type EventsHandler struct {
+	bus *events.Bus
+}
+
+func NewEventsHandler(bus *events.Bus) *EventsHandler {
+	return &EventsHandler{bus: bus}
+}
+
+// StreamReviewEvents streams the authenticated user's review pipeline events as Server-Sent
+// Events. Clients resume with the Last-Event-ID header (or ?last_event_id= for EventSource
+// polyfills that cannot set headers).
+func (h *EventsHandler) StreamReviewEvents(c *fiber.Ctx) error {
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return middleware.Abort(c, fiber.StatusUnauthorized, "unauthorized", "authentication required")
+	}
+	lastID := c.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	var lastEventID uint64
+	if lastID != "" {
+		id, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid Last-Event-ID")
+		}
+		lastEventID = id
+	}
+
+	backlog, sub, err := h.bus.Subscribe(userID, lastEventID)
+	if errors.Is(err, events.ErrTooManyStreams) {
+		return middleware.Abort(c, fiber.StatusTooManyRequests, "rate_limited", err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // disable nginx response buffering
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+		// Tell EventSource how long to wait before reconnecting
+		fmt.Fprint(w, "retry: 3000\n\n")
+		for _, e := range backlog {
+			writeSSE(w, e)
+		}
+		if w.Flush() != nil {
+			return
+		}
+		ticker := time.NewTicker(sseHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case e, open := <-sub.Events():
+				if !open {
+					return
+				}
+				writeSSE(w, e)
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	}))
+	return nil
+}
+
+func writeSSE(w *bufio.Writer, e events.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if e.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", e.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+}
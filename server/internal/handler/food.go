@@ -10,6 +10,7 @@ import (
 	"strconv"
 
 	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/middleware"
 	"github.com/bestchayapol/DishDive/internal/service"
 	"github.com/gofiber/fiber/v2"
 )
@@ -183,8 +184,14 @@ func (h *FoodHandler) AddFavorite(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return err
 	}
+	// The token decides the user; a body user_id may only repeat it
+	userID, ok, err := middleware.ActingUserID(c, req.UserID)
+	if !ok {
+		return err
+	}
+	req.UserID = userID
 
-	err := h.foodService.AddFavorite(req.UserID, req.DishID)
+	err = h.foodService.AddFavorite(req.UserID, req.DishID)
 	if err != nil {
 		return err
 	}
@@ -199,8 +206,14 @@ func (h *FoodHandler) RemoveFavorite(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return err
 	}
+	// The token decides the user; a body user_id may only repeat it
+	userID, ok, err := middleware.ActingUserID(c, req.UserID)
+	if !ok {
+		return err
+	}
+	req.UserID = userID
 
-	err := h.foodService.RemoveFavorite(req.UserID, req.DishID)
+	err = h.foodService.RemoveFavorite(req.UserID, req.DishID)
 	if err != nil {
 		return err
 	}
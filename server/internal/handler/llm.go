@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/bestchayapol/DishDive/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LLMHandler struct {
+	llmService service.LLMService
+}
+
+func NewLLMHandler(llmService service.LLMService) *LLMHandler {
+	return &LLMHandler{llmService: llmService}
+}
+
+func (h *LLMHandler) GetCacheStats(c *fiber.Ctx) error {
+	return c.JSON(h.llmService.GetCacheStats())
+}
+
+// GetUsage returns token, latency and cost aggregates; ?days= (default 7, max 90)
+func (h *LLMHandler) GetUsage(c *fiber.Ctx) error {
+	resp, err := h.llmService.GetUsage(c.QueryInt("days", 0))
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+// GetReviewUsage lists the provider calls made for ?review_id=
+func (h *LLMHandler) GetReviewUsage(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Query("review_id"), 10, 64)
+	if err != nil || id == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "missing or invalid review_id")
+	}
+	resp, err := h.llmService.GetReviewUsage(uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bestchayapol/DishDive/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MetricsHandler struct {
+	llmService service.LLMService
+	token      string
+}
+
+// NewMetricsHandler serves Prometheus metrics; a non-empty token must be sent as a Bearer token
+func NewMetricsHandler(llmService service.LLMService, token string) *MetricsHandler {
+	return &MetricsHandler{llmService: llmService, token: token}
+}
+
+// Metrics writes counters in the Prometheus text exposition format
+func (h *MetricsHandler) Metrics(c *fiber.Ctx) error {
+	if h.token != "" {
+		got := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) != 1 {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid metrics token")
+		}
+	}
+
+	var b strings.Builder
+	metric := func(name, typ, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	sample := func(name string, labels string, v float64) {
+		if labels != "" {
+			labels = "{" + labels + "}"
+		}
+		fmt.Fprintf(&b, "%s%s %s\n", name, labels, strconv.FormatFloat(v, 'g', -1, 64))
+	}
+
+	usage := h.llmService.GetLiveUsage()
+	metric("dishdive_llm_calls_total", "counter", "LLM provider calls, including failed attempts.")
+	for _, m := range usage.SinceStart {
+		sample("dishdive_llm_calls_total", modelLabel(m.Model), float64(m.Calls))
+	}
+	metric("dishdive_llm_call_errors_total", "counter", "LLM provider calls that failed.")
+	for _, m := range usage.SinceStart {
+		sample("dishdive_llm_call_errors_total", modelLabel(m.Model), float64(m.Errors))
+	}
+	metric("dishdive_llm_schema_calls_total", "counter", "LLM provider calls answered under a JSON schema.")
+	for _, m := range usage.SinceStart {
+		sample("dishdive_llm_schema_calls_total", modelLabel(m.Model), float64(m.SchemaCalls))
+	}
+	metric("dishdive_llm_fallbacks_total", "counter", "LLM answers replaced by rule-based extraction.")
+	for _, m := range usage.SinceStart {
+		sample("dishdive_llm_fallbacks_total", modelLabel(m.Model), float64(m.Fallbacks))
+	}
+	metric("dishdive_llm_tokens_total", "counter", "Tokens billed by the LLM provider.")
+	for _, m := range usage.SinceStart {
+		sample("dishdive_llm_tokens_total", modelLabel(m.Model)+`,type="prompt"`, float64(m.PromptTokens))
+		sample("dishdive_llm_tokens_total", modelLabel(m.Model)+`,type="completion"`, float64(m.CompletionTokens))
+	}
+	metric("dishdive_llm_cost_usd_total", "counter", "Estimated LLM cost in USD.")
+	for _, m := range usage.SinceStart {
+		sample("dishdive_llm_cost_usd_total", modelLabel(m.Model), m.CostUSD)
+	}
+	metric("dishdive_llm_latency_seconds_avg", "gauge", "Average LLM call latency since startup.")
+	for _, m := range usage.SinceStart {
+		sample("dishdive_llm_latency_seconds_avg", modelLabel(m.Model), m.AvgLatencyMS/1000)
+	}
+	metric("dishdive_llm_spent_today_usd", "gauge", "Estimated LLM spend since local midnight.")
+	sample("dishdive_llm_spent_today_usd", "", usage.SpentTodayUSD)
+	metric("dishdive_llm_daily_budget_usd", "gauge", "Daily LLM budget in USD (0 = unlimited).")
+	sample("dishdive_llm_daily_budget_usd", "", usage.DailyBudgetUSD)
+	metric("dishdive_review_queue_paused", "gauge", "1 while the review queue is paused by the LLM budget.")
+	sample("dishdive_review_queue_paused", "", boolGauge(usage.QueuePaused))
+
+	cache := h.llmService.GetCacheStats()
+	metric("dishdive_llm_cache_lookups_total", "counter", "LLM cache lookups by result.")
+	sample("dishdive_llm_cache_lookups_total", `result="memory_hit"`, float64(cache.MemoryHits))
+	sample("dishdive_llm_cache_lookups_total", `result="store_hit"`, float64(cache.StoreHits))
+	sample("dishdive_llm_cache_lookups_total", `result="miss"`, float64(cache.Misses))
+	metric("dishdive_llm_cache_entries", "gauge", "Entries in the in-memory LLM cache.")
+	sample("dishdive_llm_cache_entries", "", float64(cache.Entries))
+
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.SendString(b.String())
+}
+
+func modelLabel(model string) string {
+	return "model=" + strconv.Quote(model)
+}
+
+func boolGauge(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -7,16 +7,40 @@ import (
 	"os"
 
 	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/middleware"
 	"github.com/bestchayapol/DishDive/internal/service"
 	"github.com/gofiber/fiber/v2"
 )
 
 type RecommendHandler struct {
 	recommendService service.RecommendService
+	roles            middleware.RoleLookup
 }
 
-func NewRecommendHandler(recommendService service.RecommendService) *RecommendHandler {
-	return &RecommendHandler{recommendService: recommendService}
+func NewRecommendHandler(recommendService service.RecommendService, roles middleware.RoleLookup) *RecommendHandler {
+	return &RecommendHandler{recommendService: recommendService, roles: roles}
+}
+
+// authorizeReview lets the review's author and admins through. Anyone else gets the same 404
+// as for a missing review, so review IDs of other users cannot be probed.
+func (h *RecommendHandler) authorizeReview(c *fiber.Ctx, reviewID uint) error {
+	owner, err := h.recommendService.GetReviewOwner(reviewID)
+	if err != nil {
+		return err
+	}
+	userID, _ := middleware.UserID(c)
+	if owner == userID {
+		return nil
+	}
+	role, err := h.roles.GetUserRole(userID)
+	if err != nil {
+		return err
+	}
+	if role != entities.RoleAdmin {
+		return fiber.NewError(fiber.StatusNotFound, "review not found")
+	}
+	return nil
 }
 
 // New unified settings endpoints
@@ -84,6 +108,12 @@ func (h *RecommendHandler) SubmitReview(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return err
 	}
+	// The token decides the user; a body user_id may only repeat it
+	userID, ok, err := middleware.ActingUserID(c, req.UserID)
+	if !ok {
+		return err
+	}
+	req.UserID = userID
 
 	resp, err := h.recommendService.SubmitReview(req)
 	if err != nil {
@@ -123,6 +153,9 @@ func (h *RecommendHandler) GetReviewExtractStatus(c *fiber.Ctx) error {
 	if err != nil || id <= 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing or invalid review_id"})
 	}
+	if err := h.authorizeReview(c, uint(id)); err != nil {
+		return err
+	}
 	found, err := h.recommendService.HasReviewExtract(uint(id), "user")
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -135,6 +168,9 @@ func (h *RecommendHandler) GetReviewNormalizationStatus(c *fiber.Ctx) error {
 	if err != nil || id <= 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing or invalid review_id"})
 	}
+	if err := h.authorizeReview(c, uint(id)); err != nil {
+		return err
+	}
 	norm, err := h.recommendService.HasNormalizedReview(uint(id))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -148,23 +184,14 @@ func (h *RecommendHandler) GetReviewProcessingStatus(c *fiber.Ctx) error {
 	if err != nil || id <= 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing or invalid review_id"})
 	}
-	extractFound, err := h.recommendService.HasReviewExtract(uint(id), "user")
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.authorizeReview(c, uint(id)); err != nil {
+		return err
 	}
-	norm, err := h.recommendService.HasNormalizedReview(uint(id))
+	resp, err := h.recommendService.GetReviewProcessingStatus(uint(id))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	status := "pending"
-	if extractFound { status = "extracted" }
-	if norm { status = "normalized" }
-	return c.JSON(fiber.Map{
-		"review_id": id,
-		"extract_found": extractFound,
-		"normalized": norm,
-		"status": status,
-	})
+	return c.JSON(resp)
 }
 
 // Return selected environment variables (masked) to verify server config
@@ -193,6 +220,9 @@ func (h *RecommendHandler) GetLatestReviewExtract(c *fiber.Ctx) error {
 	if err != nil || id <= 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing or invalid review_id"})
 	}
+	if err := h.authorizeReview(c, uint(id)); err != nil {
+		return err
+	}
 	raw, err := h.recommendService.GetLatestReviewExtract(uint(id), "user")
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -204,3 +234,33 @@ func (h *RecommendHandler) GetLatestReviewExtract(c *fiber.Ctx) error {
 	}
 	return c.JSON(fiber.Map{"review_id": id, "raw": raw})
 }
+
+// List every stored extraction run of a review (admin diagnostics)
+func (h *RecommendHandler) GetReviewExtractHistory(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Query("review_id", ""))
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing or invalid review_id"})
+	}
+	history, err := h.recommendService.GetReviewExtractHistory(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"review_id": id, "extractions": history})
+}
+
+// Compare two extraction runs of a review: ?review_id=&from=&to= (extract IDs, default previous vs current)
+func (h *RecommendHandler) DiffReviewExtracts(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Query("review_id", ""))
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing or invalid review_id"})
+	}
+	from, to := c.QueryInt("from", 0), c.QueryInt("to", 0)
+	if from < 0 || to < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid from or to"})
+	}
+	resp, err := h.recommendService.DiffReviewExtracts(uint(id), uint(from), uint(to))
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
@@ -4,42 +4,31 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/middleware"
+	"github.com/bestchayapol/DishDive/internal/ratelimit"
 	"github.com/bestchayapol/DishDive/internal/service"
-	"github.com/bestchayapol/DishDive/internal/utils"
+	"github.com/bestchayapol/DishDive/internal/validate"
 	"github.com/gofiber/fiber/v2"
 )
 
 type userHandler struct {
 	userSer   service.UserService
-	jwtSecret string
 	uploadSer service.UploadService
 }
 
-func NewUserHandler(userSer service.UserService, jwtSecret string, uploadSer service.UploadService) userHandler {
-	return userHandler{userSer: userSer, jwtSecret: jwtSecret, uploadSer: uploadSer}
+func NewUserHandler(userSer service.UserService, uploadSer service.UploadService) userHandler {
+	return userHandler{userSer: userSer, uploadSer: uploadSer}
 }
 
 func (h *userHandler) GetUsers(c *fiber.Ctx) error {
-	usersResponse := make([]dtos.UserDataResponse, 0)
-
 	users, err := h.userSer.GetUsers()
 	if err != nil {
 		return err
 	}
 
-	for _, user := range users {
-		usersResponse = append(usersResponse, dtos.UserDataResponse{
-			UserID:   user.UserID,
-			Username: user.Username,
-
-			ImageLink:    user.ImageLink,
-			PasswordHash: user.PasswordHash,
-		})
-	}
-	return c.JSON(usersResponse)
+	return c.JSON(users)
 }
 
 func (h *userHandler) GetUserByUserId(c *fiber.Ctx) error {
@@ -51,29 +40,21 @@ func (h *userHandler) GetUserByUserId(c *fiber.Ctx) error {
 	}
 
 	userResponse := dtos.UserByUserIdDataResponse{
-		UserID:       user.UserID,
-		Username:     user.Username,
-		ImageLink:    user.ImageLink,
-		PasswordHash: user.PasswordHash,
+		UserID:    user.UserID,
+		Username:  user.Username,
+		ImageLink: user.ImageLink,
 	}
 
 	return c.JSON(userResponse)
 }
 
 func (h *userHandler) GetUserByToken(c *fiber.Ctx) error {
-	// Extract the token from the request headers
-	token := c.Get("Authorization")
-
-	// Check if the token is empty
-	if token == "" {
-		return errors.New("token is missing")
-	}
-
-	// Extract the user ID from the token
-	userIDExtract, err := utils.ExtractUserIDFromToken(strings.Replace(token, "Bearer ", "", 1), h.jwtSecret)
-	if err != nil {
-		return err
+	// User ID was extracted from the bearer token by middleware.RequireAuth
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "token is missing")
 	}
+	userIDExtract := int(userID)
 
 	user, err := h.userSer.GetUserByToken(userIDExtract)
 	if err != nil {
@@ -81,10 +62,9 @@ func (h *userHandler) GetUserByToken(c *fiber.Ctx) error {
 	}
 
 	userResponse := dtos.UserByTokenDataResponse{
-		UserID:       user.UserID,
-		Username:     user.Username,
-		ImageLink:    user.ImageLink,
-		PasswordHash: user.PasswordHash,
+		UserID:    user.UserID,
+		Username:  user.Username,
+		ImageLink: user.ImageLink,
 	}
 
 	return c.JSON(userResponse)
@@ -93,19 +73,12 @@ func (h *userHandler) GetUserByToken(c *fiber.Ctx) error {
 /////////////////////////////////////////////////////////////////////////
 
 func (h *userHandler) GetCurrentUser(c *fiber.Ctx) error {
-	// Extract the token from the request headers
-	token := c.Get("Authorization")
-
-	// Check if the token is empty
-	if token == "" {
-		return errors.New("token is missing")
-	}
-
-	// Extract the user ID from the token
-	userIDExtract, err := utils.ExtractUserIDFromToken(strings.Replace(token, "Bearer ", "", 1), h.jwtSecret)
-	if err != nil {
-		return err
+	// User ID was extracted from the bearer token by middleware.RequireAuth
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "token is missing")
 	}
+	userIDExtract := int(userID)
 
 	user, err := h.userSer.GetCurrentUser(userIDExtract)
 	if err != nil {
@@ -113,10 +86,9 @@ func (h *userHandler) GetCurrentUser(c *fiber.Ctx) error {
 	}
 
 	userResponse := dtos.CurrentUserResponse{
-		UserID:       user.UserID,
-		Username:     user.Username,
-		ImageLink:    user.ImageLink,
-		PasswordHash: user.PasswordHash,
+		UserID:    user.UserID,
+		Username:  user.Username,
+		ImageLink: user.ImageLink,
 	}
 
 	return c.JSON(userResponse)
@@ -167,6 +139,12 @@ func (h *userHandler) PatchEditUserProfileByUserId(c *fiber.Ctx) error {
 
 	fmt.Printf("DEBUG: Received username: '%s'\n", username)
 
+	if username != "" {
+		if err := validate.EditUserProfileRequest(dtos.EditUserProfileByUserIdRequest{Username: &username}); err != nil {
+			return err
+		}
+	}
+
 	var imageURL *string
 
 	// Check if a file is uploaded
@@ -217,12 +195,9 @@ func (h *userHandler) Register(c *fiber.Ctx) error {
 	username := c.FormValue("user_name")
 	password := c.FormValue("password_hash")
 
-	// Validate required fields
-	if username == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Username is required")
-	}
-	if password == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Password is required")
+	// Validate fields before uploading anything (422 with field-level details)
+	if err := validate.RegisterRequest(dtos.RegisterRequest{Username: &username, PasswordHash: password}); err != nil {
+		return err
 	}
 
 	// Check if a file is uploaded
@@ -246,6 +221,10 @@ func (h *userHandler) Register(c *fiber.Ctx) error {
 
 	response, err := h.userSer.Register(request)
 	if err != nil {
+		var ve *validate.Error
+		if errors.As(err, &ve) {
+			return err
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
@@ -262,10 +241,125 @@ func (h *userHandler) Login(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Username and Password are required")
 	}
 
-	response, err := h.userSer.Login(request, h.jwtSecret)
+	response, err := h.userSer.Login(request, c.IP())
 	if err != nil {
+		var limited *ratelimit.Error
+		if errors.As(err, &limited) {
+			return err
+		}
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
 	return c.JSON(response)
 }
+
+func (h *userHandler) RefreshToken(c *fiber.Ctx) error {
+	var request dtos.RefreshTokenRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	response, err := h.userSer.RefreshToken(request.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(response)
+}
+
+func (h *userHandler) Logout(c *fiber.Ctx) error {
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "token is missing")
+	}
+
+	// Body is optional; without it the session of the presented access token is closed
+	var request dtos.LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	if err := h.userSer.Logout(userID, middleware.SessionID(c), request.RefreshToken); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func (h *userHandler) RevokeAllSessions(c *fiber.Ctx) error {
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "token is missing")
+	}
+
+	if err := h.userSer.RevokeAllSessions(userID); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func (h *userHandler) ChangePassword(c *fiber.Ctx) error {
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "token is missing")
+	}
+
+	var request dtos.ChangePasswordRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.userSer.ChangePassword(userID, middleware.SessionID(c), request); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func (h *userHandler) RequestPasswordReset(c *fiber.Ctx) error {
+	var request dtos.RequestPasswordResetRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.userSer.RequestPasswordReset(request); err != nil {
+		return err
+	}
+
+	// Same answer whether or not the user exists
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"success": true})
+}
+
+func (h *userHandler) ResetPassword(c *fiber.Ctx) error {
+	var request dtos.ResetPasswordRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.userSer.ResetPassword(request); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"success": true})
+}
+
+func (h *userHandler) DeleteAccount(c *fiber.Ctx) error {
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "token is missing")
+	}
+
+	var request dtos.DeleteAccountRequest
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.userSer.DeleteAccount(userID, request); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"success": true})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/middleware"
+	"github.com/bestchayapol/DishDive/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+const storedHash = "$2a$10$hash-that-must-not-leak"
+
+// userStub returns users with a password hash set, as the repository does. Methods the
+// tested routes do not call are left to the embedded nil interface.
+type userStub struct {
+	service.UserService
+}
+
+func (userStub) user(id int) (*entities.User, error) {
+	name := "somchai"
+	return &entities.User{UserID: uint(id), Username: &name, PasswordHash: storedHash, Role: entities.RoleUser}, nil
+}
+
+func (s userStub) GetUserByUserId(id int) (*entities.User, error) { return s.user(id) }
+func (s userStub) GetUserByToken(id int) (*entities.User, error)  { return s.user(id) }
+func (s userStub) GetCurrentUser(id int) (*entities.User, error)  { return s.user(id) }
+func (s userStub) GetProfileOfCurrentUserByUserId(id int) (*entities.User, error) {
+	return s.user(id)
+}
+func (s userStub) GetEditUserProfileByUserId(id int) (*entities.User, error) { return s.user(id) }
+
+func (userStub) GetUsers() ([]dtos.AdminUserView, error) {
+	name := "somchai"
+	return []dtos.AdminUserView{{UserID: 1, Username: &name, Role: entities.RoleUser, ActiveSessions: 1}}, nil
+}
+
+// TestUserEndpointsNeverSerialisePasswordHash calls the user handlers behind the same error
+// handler and sensitive field guard as main.go. A handler that serialises the hash fails the
+// test either way: the guard turns the response into a 500, and without it the body leaks.
+func TestUserEndpointsNeverSerialisePasswordHash(t *testing.T) {
+	h := NewUserHandler(userStub{}, nil)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(middleware.GuardSensitiveFields())
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.LocalUserID, uint(1))
+		return c.Next()
+	})
+	app.Get("/GetUsers", h.GetUsers)
+	app.Get("/GetUserByToken", h.GetUserByToken)
+	app.Get("/GetCurrentUser", h.GetCurrentUser)
+	app.Get("/GetUserByUserId/:UserID", h.GetUserByUserId)
+	app.Get("/GetProfileOfCurrentUserByUserId/:UserID", h.GetProfileOfCurrentUserByUserId)
+	app.Get("/GetEditUserProfileByUserId/:UserID", h.GetEditUserProfileByUserId)
+
+	for _, path := range []string{
+		"/GetUsers",
+		"/GetUserByToken",
+		"/GetCurrentUser",
+		"/GetUserByUserId/1",
+		"/GetProfileOfCurrentUserByUserId/1",
+		"/GetEditUserProfileByUserId/1",
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("GET %s: status %d, want 200 (body %s)", path, resp.StatusCode, body)
+		}
+		if strings.Contains(string(body), "password_hash") || strings.Contains(string(body), storedHash) {
+			t.Errorf("GET %s: body leaks the password hash: %s", path, body)
+		}
+	}
+}
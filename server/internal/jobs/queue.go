@@ -0,0 +1,268 @@
+// Package jobs runs durable review-processing jobs stored in the review_jobs table.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/events"
+	"github.com/bestchayapol/DishDive/internal/repository"
+)
+
+// Handler processes one claimed job; a returned error schedules a retry
+type Handler func(ctx context.Context, job entities.ReviewJob) error
+
+// Gate can hold back job processing, e.g. while the daily LLM budget is exhausted
+type Gate interface {
+	Paused(now time.Time) (paused bool, reason string)
+}
+
+// Config tunes the worker pool. Zero values fall back to the defaults below.
+type Config struct {
+	Concurrency  int           // number of workers
+	PollInterval time.Duration // idle wait between claims when no job is due
+	MaxAttempts  int           // attempts before a job is marked failed
+	BackoffBase  time.Duration // delay before the first retry, doubled per attempt
+	BackoffMax   time.Duration // upper bound for the retry delay
+	JobTimeout   time.Duration // deadline for a single attempt
+	StuckAfter   time.Duration // running jobs locked longer than this are recovered
+	Gate         Gate          // optional; no jobs are claimed while it reports paused
+}
+
+const (
+	defaultConcurrency  = 2
+	defaultPollInterval = 2 * time.Second
+	defaultMaxAttempts  = 5
+	defaultBackoffBase  = 30 * time.Second
+	defaultBackoffMax   = 30 * time.Minute
+	defaultJobTimeout   = 2 * time.Minute
+	defaultStuckAfter   = 10 * time.Minute
+	maxErrorLength      = 2000
+)
+
+func (c Config) withDefaults() Config {
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultConcurrency
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultPollInterval
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultMaxAttempts
+	}
+	if c.BackoffBase <= 0 {
+		c.BackoffBase = defaultBackoffBase
+	}
+	if c.BackoffMax <= 0 {
+		c.BackoffMax = defaultBackoffMax
+	}
+	if c.JobTimeout <= 0 {
+		c.JobTimeout = defaultJobTimeout
+	}
+	if c.StuckAfter <= 0 {
+		c.StuckAfter = defaultStuckAfter
+	}
+	return c
+}
+
+// ReviewQueue enqueues review jobs and runs the worker pool that processes them
+type ReviewQueue struct {
+	repo repository.ReviewJobRepository
+	cfg  Config
+	bus  *events.Bus
+	wake chan struct{}
+	name string // identifies this process in review_jobs.locked_by
+
+	paused atomic.Bool // last Gate result, so the pause is logged once
+}
+
+// NewReviewQueue creates the queue; failed attempts are published on bus (may be nil)
+func NewReviewQueue(repo repository.ReviewJobRepository, cfg Config, bus *events.Bus) *ReviewQueue {
+	host, _ := os.Hostname()
+	return &ReviewQueue{
+		repo: repo,
+		cfg:  cfg.withDefaults(),
+		bus:  bus,
+		wake: make(chan struct{}, 1),
+		name: fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// Enqueue adds a job and wakes an idle worker
+func (q *ReviewQueue) Enqueue(job *entities.ReviewJob) error {
+	if err := q.repo.EnqueueReviewJob(job); err != nil {
+		return err
+	}
+	q.Notify()
+	return nil
+}
+
+// Notify wakes an idle worker after a job was inserted elsewhere (e.g. with its review)
+func (q *ReviewQueue) Notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start recovers stuck jobs and launches the workers. Workers stop when ctx is cancelled.
+func (q *ReviewQueue) Start(ctx context.Context, handle Handler) error {
+	// Other replicas may still be running jobs, so only jobs locked longer than StuckAfter are
+	// taken back, as in recoverLoop
+	n, err := q.repo.RecoverStuckReviewJobs(time.Now().Add(-q.cfg.StuckAfter), q.cfg.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[jobs] recovered %d stuck review job(s)", n)
+	}
+	for i := 0; i < q.cfg.Concurrency; i++ {
+		go q.work(ctx, i, handle)
+	}
+	go q.recoverLoop(ctx)
+	log.Printf("[jobs] review queue started with %d worker(s)", q.cfg.Concurrency)
+	return nil
+}
+
+func (q *ReviewQueue) work(ctx context.Context, worker int, handle Handler) {
+	for {
+		if q.gated() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(q.cfg.PollInterval):
+			}
+			continue
+		}
+		job, err := q.repo.ClaimReviewJob(time.Now(), fmt.Sprintf("%s/%d", q.name, worker))
+		if err != nil {
+			log.Printf("[jobs] worker %d: claim failed: %v", worker, err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			case <-time.After(q.cfg.PollInterval):
+			}
+			continue
+		}
+		q.run(ctx, worker, *job, handle)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// gated checks the Gate and logs when processing pauses or resumes. Paused jobs stay pending.
+func (q *ReviewQueue) gated() bool {
+	if q.cfg.Gate == nil {
+		return false
+	}
+	paused, reason := q.cfg.Gate.Paused(time.Now())
+	if was := q.paused.Swap(paused); was != paused {
+		if paused {
+			log.Printf("[jobs] review queue paused: %s", reason)
+		} else {
+			log.Printf("[jobs] review queue resumed")
+		}
+	}
+	return paused
+}
+
+// run executes one attempt and records its outcome
+func (q *ReviewQueue) run(ctx context.Context, worker int, job entities.ReviewJob, handle Handler) {
+	jobCtx, cancel := context.WithTimeout(ctx, q.cfg.JobTimeout)
+	err := safeHandle(jobCtx, job, handle)
+	cancel()
+
+	if err == nil {
+		if err := q.repo.CompleteReviewJob(&job); errors.Is(err, repository.ErrReviewJobLockLost) {
+			log.Printf("[jobs] worker %d: job %d was recovered while it ran; leaving it to the newer attempt", worker, job.JobID)
+		} else if err != nil {
+			log.Printf("[jobs] worker %d: could not mark job %d succeeded: %v", worker, job.JobID, err)
+		}
+		return
+	}
+
+	msg := err.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+	var retryAt *time.Time
+	if job.Attempts < q.cfg.MaxAttempts {
+		at := time.Now().Add(q.backoff(job.Attempts))
+		retryAt = &at
+		log.Printf("[jobs] job %d (review %d) attempt %d failed, retrying at %s: %v", job.JobID, job.SourceID, job.Attempts, at.Format(time.RFC3339), err)
+	} else {
+		log.Printf("[jobs] job %d (review %d) failed permanently after %d attempts: %v", job.JobID, job.SourceID, job.Attempts, err)
+	}
+	if err := q.repo.FailReviewJob(&job, msg, retryAt); errors.Is(err, repository.ErrReviewJobLockLost) {
+		// Another attempt owns the job now and reports its own outcome
+		log.Printf("[jobs] worker %d: job %d was recovered while it ran; dropping this failure", worker, job.JobID)
+		return
+	} else if err != nil {
+		log.Printf("[jobs] worker %d: could not record failure of job %d: %v", worker, job.JobID, err)
+	}
+	q.bus.Publish(events.Event{
+		UserID: job.UserID,
+		Type:   events.ReviewFailed,
+		Data: dtos.ReviewEventData{
+			ReviewID:  job.SourceID,
+			DishID:    job.DishID,
+			Attempt:   job.Attempts,
+			Error:     msg,
+			WillRetry: retryAt != nil,
+			NextRunAt: retryAt,
+		},
+	})
+}
+
+// backoff returns BackoffBase * 2^(attempt-1), capped at BackoffMax, with up to 20% jitter
+func (q *ReviewQueue) backoff(attempt int) time.Duration {
+	d := q.cfg.BackoffBase
+	for i := 1; i < attempt && d < q.cfg.BackoffMax; i++ {
+		d *= 2
+	}
+	if d > q.cfg.BackoffMax {
+		d = q.cfg.BackoffMax
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// recoverLoop periodically returns jobs whose worker died (e.g. another replica crashed)
+func (q *ReviewQueue) recoverLoop(ctx context.Context) {
+	ticker := time.NewTicker(q.cfg.StuckAfter / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := q.repo.RecoverStuckReviewJobs(time.Now().Add(-q.cfg.StuckAfter), q.cfg.MaxAttempts)
+			if err != nil {
+				log.Printf("[jobs] stuck job recovery failed: %v", err)
+			} else if n > 0 {
+				log.Printf("[jobs] recovered %d stuck review job(s)", n)
+			}
+		}
+	}
+}
+
+// safeHandle turns a handler panic into a job error so one bad review cannot kill a worker
+func safeHandle(ctx context.Context, job entities.ReviewJob, handle Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handle(ctx, job)
+}
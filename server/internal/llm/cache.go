@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// CacheStore is the durable cache tier, keyed by CacheKey. Get returns (nil, nil) on a miss.
+type CacheStore interface {
+	GetCachedExtract(ctx context.Context, key string) (*ExtractResponse, error)
+	PutCachedExtract(ctx context.Context, key string, promptVersion string, resp *ExtractResponse) error
+}
+
+// CacheStats are cumulative counters since process start
+type CacheStats struct {
+	MemoryHits  uint64
+	StoreHits   uint64
+	Misses      uint64
+	Writes      uint64
+	StoreErrors uint64
+	Entries     int // entries currently in the memory tier
+	Capacity    int
+}
+
+// CacheKey is the content address of one extraction: sha256 over the inputs that determine
+// the model's answer
+func CacheKey(restaurant, review, hintDish, model, promptVersion string) string {
+	// JSON encoding keeps field boundaries unambiguous
+	b, _ := json.Marshal([]string{
+		strings.TrimSpace(restaurant),
+		strings.TrimSpace(review),
+		strings.TrimSpace(hintDish),
+		model,
+		promptVersion,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Cache is a two-tier extraction cache: an in-memory LRU in front of an optional CacheStore
+type Cache struct {
+	store CacheStore
+
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front = most recently used
+	entries  map[string]*list.Element
+
+	memoryHits, storeHits, misses, writes, storeErrors atomic.Uint64
+}
+
+type cacheEntry struct {
+	key  string
+	resp *ExtractResponse
+}
+
+// NewCache builds a cache holding up to capacity entries in memory; store may be nil
+func NewCache(capacity int, store CacheStore) *Cache {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &Cache{store: store, capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *Cache) get(ctx context.Context, key string) *ExtractResponse {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		resp := el.Value.(*cacheEntry).resp
+		c.mu.Unlock()
+		c.memoryHits.Add(1)
+		return cloneResponse(resp)
+	}
+	c.mu.Unlock()
+
+	if c.store != nil {
+		resp, err := c.store.GetCachedExtract(ctx, key)
+		if err != nil {
+			c.storeErrors.Add(1)
+			log.Printf("[llm] cache store read failed: %v", err)
+		} else if resp != nil {
+			c.storeHits.Add(1)
+			c.remember(key, resp)
+			return cloneResponse(resp)
+		}
+	}
+	c.misses.Add(1)
+	return nil
+}
+
+func (c *Cache) put(ctx context.Context, key string, promptVersion string, resp *ExtractResponse) {
+	c.remember(key, cloneResponse(resp))
+	c.writes.Add(1)
+	if c.store != nil {
+		if err := c.store.PutCachedExtract(ctx, key, promptVersion, resp); err != nil {
+			c.storeErrors.Add(1)
+			log.Printf("[llm] cache store write failed: %v", err)
+		}
+	}
+}
+
+// remember inserts into the memory tier, evicting the least recently used entry when full
+func (c *Cache) remember(key string, resp *ExtractResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*cacheEntry).resp = resp
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, resp: resp})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Stats returns a snapshot of the counters; a nil cache reports zeros
+func (c *Cache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+	return CacheStats{
+		MemoryHits:  c.memoryHits.Load(),
+		StoreHits:   c.storeHits.Load(),
+		Misses:      c.misses.Load(),
+		Writes:      c.writes.Load(),
+		StoreErrors: c.storeErrors.Load(),
+		Entries:     entries,
+		Capacity:    c.capacity,
+	}
+}
+
+// cloneResponse copies the items slice so callers may edit results (extract.Service overrides
+// cuisine and restriction) without touching cached entries
+func cloneResponse(resp *ExtractResponse) *ExtractResponse {
+	out := *resp
+	out.Items = append([]ExtractItem(nil), resp.Items...)
+	return &out
+}
+
+// cachedExtractor answers repeated inputs from the cache and stores results of real provider calls
+type cachedExtractor struct {
+	inner Extractor
+	cache *Cache
+}
+
+// WithCache wraps inner so identical inputs are only sent to the provider once
+func WithCache(inner Extractor, cache *Cache) Extractor {
+	return &cachedExtractor{inner: inner, cache: cache}
+}
+
+func (e *cachedExtractor) Model() string { return e.inner.Model() }
+
+func (e *cachedExtractor) Prompt() Prompt { return e.inner.Prompt() }
+
+func (e *cachedExtractor) Extract(ctx context.Context, restaurant, review string, hintDish string) (*ExtractResponse, error) {
+	prompt := e.inner.Prompt()
+	key := CacheKey(restaurant, review, hintDish, e.inner.Model(), prompt.CacheID())
+	if resp := e.cache.get(ctx, key); resp != nil {
+		return resp, nil
+	}
+	resp, err := e.inner.Extract(ctx, restaurant, review, hintDish)
+	if err != nil {
+		return nil, err
+	}
+	// An empty model means no provider call was made (no API key, open circuit); don't pin that result.
+	// Rule-based fallbacks and answers in a degraded format would outlive the outage that caused them.
+	if resp != nil && resp.Model != "" && !resp.UsedFallback && !resp.Degraded {
+		e.cache.put(ctx, key, prompt.Version, resp)
+	}
+	return resp, nil
+}
@@ -4,30 +4,47 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
+	"io"
+	"log"
 	"net/http"
-	"os"
-	"regexp"
 	"strings"
 	"time"
 )
 
+// OpenAI's public endpoint; any server speaking the same chat completions API can replace it
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// Client is an OpenAI-compatible chat completions backend (OpenAI, vLLM, llama.cpp, LM Studio, ...)
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 	apiKey     string
 	model      string
+	prompt     Prompt
+	reask      bool   // set by New from Config.ReaskOnInvalid
+	format     string // FormatJSONSchema or FormatJSONObject
+
+	schemaOff schemaFallback // the server rejected json_schema; use json_object for a while
 }
 
-func NewClientFromEnv() *Client {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	model := os.Getenv("OPENAI_MODEL")
+// NewClient builds an OpenAI-compatible client; an empty baseURL uses DefaultOpenAIBaseURL
+func NewClient(baseURL, apiKey, model string, timeout time.Duration, prompt Prompt) *Client {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
 	if model == "" {
 		model = "gpt-4o-mini"
 	}
+	if timeout <= 0 {
+		timeout = 35 * time.Second
+	}
 	return &Client{
-		httpClient: &http.Client{Timeout: 35 * time.Second},
+		httpClient: &http.Client{Timeout: timeout},
+		baseURL:    strings.TrimRight(baseURL, "/"),
 		apiKey:     apiKey,
 		model:      model,
+		prompt:     prompt,
+		format:     FormatJSONSchema,
 	}
 }
 
@@ -37,9 +54,9 @@ type Message struct {
 }
 
 type chatRequest struct {
-	Model          string            `json:"model"`
-	Messages       []Message         `json:"messages"`
-	ResponseFormat map[string]string `json:"response_format,omitempty"`
+	Model          string    `json:"model"`
+	Messages       []Message `json:"messages"`
+	ResponseFormat any       `json:"response_format,omitempty"`
 }
 
 type chatResponse struct {
@@ -48,6 +65,10 @@ type chatResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
 }
 
 type Dish struct {
@@ -59,7 +80,20 @@ type Dish struct {
 }
 
 type ExtractResponse struct {
-	Items []ExtractItem `json:"items"`
+	Items        []ExtractItem `json:"items"`
+	Model        string        `json:"model,omitempty"`         // model that produced the items ("" when no LLM call was made)
+	UsedFallback bool          `json:"used_fallback,omitempty"` // items come from ruleBasedExtract
+	Usage        Usage         `json:"-"`                       // tokens billed for this call; not cached
+	Format       string        `json:"-"`                       // response format the provider answered in
+	Degraded     bool          `json:"-"`                       // the provider rejected the configured format and a weaker one was used
+
+	// Prompt that produced the items ("" when no LLM call was made)
+	PromptVersion string `json:"prompt_version,omitempty"`
+	PromptHash    string `json:"prompt_hash,omitempty"`
+
+	// Problems found in the model answer and repaired or dropped by ValidateItems
+	Warnings []ValidationWarning `json:"warnings,omitempty"`
+	Reasked  bool                `json:"reasked,omitempty"` // the model was asked once more to fix its answer
 }
 
 // Python-parity schema expected by normalization and legacy data
@@ -74,113 +108,182 @@ type ExtractItem struct {
 	} `json:"sentiment"`
 }
 
-const systemPrompt = `You are an extraction engine for restaurant reviews (Thai or English).
-
-TASK: Return a JSON object with key "items" (raw JSON only) containing an array of dish objects for this single review.
-
-Restaurant: {restaurant}
-Review: "{review}"
-
-RULES (concise):
-1. Split multiple dishes joined by และ, กับ, และก็, หรือ, ,
-2. Use the most specific dish phrase available.
-3. Keep a dish even without sentiments (empty lists allowed).
-4. Sentiment lists capture taste/texture/doneness/temperature/presentation.
-5. If a dish hint is implied in the review text (e.g., prepended context like "(dish mentioned: …)"), attach general quality/price sentiments to that hinted dish even if the text is generic (e.g., "my kids love it", "delicious, cheap").
-6. DO NOT output generic placeholders like เมนูรวม/อาหาร/เมนู unless a real dish is identified.
-7. If no valid dish is present, return {"items": []} exactly.
-8. Copy the restaurant name exactly in every object.
-9. cuisine: one of [thai,chinese,japanese,korean,italian,american,vietnamese,indian,mexican,fusion,others].
-10. restriction: one of ["halal","vegan","buddhist vegan", null].
-11. For English reviews, you may keep English sentiment words as-is (e.g., tasty, delicious, crispy, salty, bland, chewy, greasy, fishy, sweet, affordable, expensive). Use short tokens.
-
-Schema:
-{
-	"items": [
-		{
-			"restaurant": "…",
-			"dish": "…",
-			"cuisine": "…",
-			"restriction": null,
-			"sentiment": {"positive":[],"negative":[]}
-		}
-	]
-}
-
-Return ONLY the JSON object.`
-
-// Forbidden/generic dish placeholders we must avoid keeping
-var forbiddenDishes = map[string]struct{}{
-	"เมนูรวม": {}, "เมนูต่างๆ": {}, "อาหารรวม": {}, "assorted": {}, "อาหาร": {}, "เมนู": {},
-}
-
-// Ingredient roots (subset; expand as needed to match Python INGREDIENT_ROOTS)
-var ingredientRoots = []string{
-	"ต้มยำ", "ลาบ", "ส้มตำ", "ก้อย", "ผัด", "แกง", "เกี๊ยวซ่า", "เกี๊ยว",
-	"เต้าหู้", "ปลาหมึก", "ปลากระพง", "คอหมูย่าง", "หมูย่าง", "ไก่ทอด", "ปีกไก่",
-	"กระดูกหมู", "ผัดไทย", "กะเพรา", "กะเพร", "ข้าวผัด", "ปลาเผา", "ยำ",
-}
-
-var rbMethods = []string{"ทอด", "ผัด", "ย่าง", "นึ่ง", "ต้ม", "แกง", "เผา", "อบ"}
-var rbFlavorParts = []string{"มะนาว", "กระเทียม", "พริก", "ปลาร้า", "สมุนไพร"}
-var rbPositive = []string{"อร่อย", "แซ่บ", "เด็ด", "ดี", "หอม", "กรอบ", "เข้มข้น", "สด", "นุ่ม", "หวาน", "กลมกล่อม"}
-var rbNegative = []string{"เค็ม", "จืด", "เหนียว", "มันไป", "หวานไป", "เผ็ดไป", "ไม่อร่อย", "คาว"}
+func (c *Client) Model() string { return c.model }
 
-var dishRegex = regexp.MustCompile(`(ต้มยำ|ลาบ|ส้มตำ|ก้อย|ผัด|แกง|เกี๊ยวซ่า|เกี๊ยว|เต้าหู้|ปลาหมึก|ปลากระพง|คอหมูย่าง|หมูย่าง|ไก่ทอด|ปีกไก่|กระดูกหมู|ผัดไทย|กะเพรา|กะเพร|ข้าวผัด|ปลาเผา|ยำ)`)
+func (c *Client) Prompt() Prompt { return c.prompt }
 
 func (c *Client) Extract(ctx context.Context, restaurant, review string, hintDish string) (*ExtractResponse, error) {
-	if c.apiKey == "" {
+	// Without a key only the public OpenAI endpoint is skipped; local servers usually need none
+	if c.apiKey == "" && c.baseURL == DefaultOpenAIBaseURL {
 		return &ExtractResponse{Items: nil}, nil
 	}
-	if hintDish != "" {
-		review = "(dish mentioned: " + hintDish + ") " + review
+	return extractWithChat(ctx, c.chat, c.model, c.prompt, c.reask, restaurant, review, hintDish)
+}
+
+// chat asks for schema-constrained output and falls back to JSON mode for servers without it
+func (c *Client) chat(ctx context.Context, messages []Message) (chatReply, error) {
+	format := c.format
+	if format == FormatJSONSchema && c.schemaOff.active() {
+		format = FormatJSONObject
+	}
+	reply, err := c.send(ctx, messages, format)
+	if err != nil && format == FormatJSONSchema && schemaRejected(err, "response_format", "json_schema") {
+		c.schemaOff.start()
+		log.Printf("[llm] %s rejected json_schema output, using json_object for %s: %v", c.baseURL, schemaRetryAfter, err)
+		reply, err = c.send(ctx, messages, FormatJSONObject)
 	}
-	user := "Restaurant: " + restaurant + "\nReview: " + review
+	reply.Degraded = reply.Format != c.format
+	return reply, err
+}
 
+// send makes one chat completion request
+func (c *Client) send(ctx context.Context, messages []Message, format string) (chatReply, error) {
 	reqBody := chatRequest{
-		Model: c.model,
-		Messages: []Message{
-				{Role: "system", Content: systemPrompt},
-				{Role: "user", Content: user},
+		Model:          c.model,
+		Messages:       messages,
+		ResponseFormat: map[string]string{"type": FormatJSONObject},
+	}
+	if format == FormatJSONSchema {
+		reqBody.ResponseFormat = map[string]any{
+			"type": FormatJSONSchema,
+			"json_schema": map[string]any{
+				"name":   "review_extraction",
+				"strict": true,
+				"schema": extractionSchema,
 			},
-			ResponseFormat: map[string]string{"type": "json_object"},
 		}
+	}
 	data, _ := json.Marshal(reqBody)
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/chat/completions", bytes.NewReader(data))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(data))
 	if err != nil {
-		return nil, err
+		return chatReply{}, err
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	}
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, err
+		return chatReply{}, transportError("openai", ctx, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, errors.New("openai request failed: " + resp.Status)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return chatReply{}, statusError("openai", resp, body)
 	}
 	var ch chatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ch); err != nil {
+		return chatReply{}, malformedError("openai", err)
+	}
+	content := ""
+	if len(ch.Choices) > 0 {
+		content = ch.Choices[0].Message.Content
+	}
+	return chatReply{
+		Content: content,
+		Usage:   Usage{PromptTokens: ch.Usage.PromptTokens, CompletionTokens: ch.Usage.CompletionTokens},
+		Format:  format,
+	}, nil
+}
+
+// chatReply is a provider's answer to one conversation
+type chatReply struct {
+	Content  string
+	Usage    Usage
+	Format   string // response format actually used
+	Degraded bool   // Format is weaker than the one configured
+}
+
+// chatFunc sends one conversation to a provider
+type chatFunc func(ctx context.Context, messages []Message) (chatReply, error)
+
+// extractWithChat runs the extraction conversation shared by the HTTP providers. With reask,
+// an answer that fails validation is sent back once together with the problems found.
+func extractWithChat(ctx context.Context, chat chatFunc, model string, prompt Prompt, reask bool, restaurant, review, hintDish string) (*ExtractResponse, error) {
+	review = withHint(review, hintDish)
+	messages := []Message{
+		{Role: "system", Content: prompt.Text},
+		{Role: "user", Content: userPrompt(restaurant, review)},
+	}
+	reply, err := chat(ctx, messages)
+	if err != nil {
 		return nil, err
 	}
-	if len(ch.Choices) == 0 || ch.Choices[0].Message.Content == "" {
+	usage := reply.Usage
+	degraded := reply.Degraded
+	out := finishExtract(restaurant, review, hintDish, reply.Content, model)
+
+	if reask && !out.UsedFallback && len(out.Warnings) > 0 {
+		retry := append(messages,
+			Message{Role: "assistant", Content: reply.Content},
+			Message{Role: "user", Content: reaskPrompt(out.Warnings)},
+		)
+		reply2, err := chat(ctx, retry)
+		degraded = degraded || reply2.Degraded
+		usage.PromptTokens += reply2.Usage.PromptTokens
+		usage.CompletionTokens += reply2.Usage.CompletionTokens
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		first := out.Warnings
+		for i := range first {
+			first[i].Round = 1
+		}
+		// Keep the repaired first answer unless the second one is usable
+		if err == nil {
+			if second := finishExtract(restaurant, review, hintDish, reply2.Content, model); !second.UsedFallback {
+				for i := range second.Warnings {
+					second.Warnings[i].Round = 2
+				}
+				second.Warnings = append(first, second.Warnings...)
+				out = second
+			}
+		}
+		out.Reasked = true
+	}
+	out.PromptVersion, out.PromptHash = prompt.Version, prompt.Hash
+	out.Usage = usage
+	out.Format = reply.Format
+	out.Degraded = degraded
+	return out, nil
+}
+
+// withHint prepends the selected dish so the model (and the fallback) attach generic praise to it
+func withHint(review, hintDish string) string {
+	if hintDish == "" {
+		return review
+	}
+	return "(dish mentioned: " + hintDish + ") " + review
+}
+
+func userPrompt(restaurant, review string) string {
+	return "Restaurant: " + restaurant + "\nReview: " + review
+}
+
+// finishExtract parses and validates a model reply and falls back to ruleBasedExtract when it is
+// empty, unparsable or has no valid dish left. review must already include the hint.
+func finishExtract(restaurant, review, hintDish, content, model string) *ExtractResponse {
+	if content == "" {
 		// No content; try rule-based fallback
 		items := ruleBasedExtract(restaurant, review)
-		return &ExtractResponse{Items: items}, nil
+		return &ExtractResponse{Items: items, Model: model, UsedFallback: true}
+	}
+	// Parse response robustly, validate it and decide if fallback is needed
+	arr, ok := safeParseToArray(content)
+	var warnings []ValidationWarning
+	if ok {
+		arr, warnings = ValidateItems(arr, restaurant)
 	}
-	// Parse response robustly and decide if fallback is needed
-	arr, ok := safeParseToArray(ch.Choices[0].Message.Content)
 	if !ok || needsFallback(arr, review) {
 		items := ruleBasedExtract(restaurant, review)
 		// If a hint dish is present and rule-based returned at least one, override first dish name
 		if hintDish != "" && len(items) > 0 {
 			items[0].Dish = hintDish
 		}
-		return &ExtractResponse{Items: items}, nil
+		return &ExtractResponse{Items: items, Model: model, UsedFallback: true, Warnings: warnings}
 	}
-	return &ExtractResponse{Items: arr}, nil
+	return &ExtractResponse{Items: arr, Model: model, Warnings: warnings}
 }
 
 // safeParseToArray attempts to find and parse a JSON array within raw content
@@ -216,125 +319,33 @@ func safeParseToArray(raw string) ([]ExtractItem, bool) {
 func needsFallback(arr []ExtractItem, review string) bool {
 	if len(arr) == 0 {
 		// if the review clearly mentions dish tokens, try to synthesize
-		if dishRegex.FindStringIndex(review) != nil {
+		if CurrentLexicon().MentionsDish(review) {
 			return true
 		}
 		return true
 	}
+	lex := CurrentLexicon()
 	allForbidden := true
 	for _, it := range arr {
 		d := strings.TrimSpace(it.Dish)
 		if d == "" {
 			continue
 		}
-		if _, bad := forbiddenDishes[d]; !bad {
+		if !lex.IsForbiddenDish(d) {
 			allForbidden = false
 		}
 	}
 	return allForbidden
 }
 
+// ruleBasedExtract is the lexicon-based extraction used when the model's answer is unusable;
+// the language is chosen from the review's script
 func ruleBasedExtract(restaurant, review string) []ExtractItem {
-	// Collect candidate dishes by scanning for ingredient roots and optional method/flavor parts after
-	type void = struct{}
-	candidates := map[string]void{}
-	// Thai is case-insensitive; operate on original string
-	// Build candidates by scanning occurrences of each ingredient root
-	for _, ing := range ingredientRoots {
-		idx := 0
-		for {
-			k := strings.Index(review[idx:], ing)
-			if k < 0 {
-				break
-			}
-			start := idx + k
-			tail := review[start+len(ing):]
-			// take first few tokens after the ingredient
-			extra := []string{}
-			parts := strings.Fields(tail)
-			for _, p := range parts {
-				p = strings.Trim(p, ".,!?:;\"\t")
-				if p == "" {
-					continue
-				}
-				if startsWithAny(p, rbMethods) || startsWithAny(p, rbFlavorParts) {
-					extra = append(extra, p)
-					if len(extra) >= 3 {
-						break
-					}
-				} else {
-					break
-				}
-			}
-			phrase := ing + strings.Join(extra, "")
-			// trim trailing sentiments glued
-			for _, s := range append(rbPositive, rbNegative...) {
-				// TrimSuffix is a no-op if the suffix isn't present, so no need to check HasSuffix
-				phrase = strings.TrimSuffix(phrase, s)
-			}
-			phrase = strings.TrimSpace(phrase)
-			if phrase != "" {
-				if _, bad := forbiddenDishes[phrase]; !bad {
-					candidates[phrase] = void{}
-				}
-			}
-			idx = start + len(ing)
-			if idx >= len(review) {
-				break
-			}
-		}
-	}
-	if len(candidates) == 0 {
-		// fallback to bare ingredients present
-		for _, ing := range ingredientRoots {
-			if strings.Contains(review, ing) {
-				if _, bad := forbiddenDishes[ing]; !bad {
-					candidates[ing] = void{}
-				}
-			}
-		}
+	lex := CurrentLexicon()
+	if detectScript(review) == scriptLatin {
+		return ruleBasedExtractEnglish(restaurant, review, lex)
 	}
-	// sentiment window within 40 chars after occurrence of each candidate
-	items := []ExtractItem{}
-	for dish := range candidates {
-		pos := []string{}
-		neg := []string{}
-		// naive scan: capture tokens within 40 chars after any occurrence
-		idx := 0
-		for {
-			k := strings.Index(review[idx:], dish)
-			if k < 0 {
-				break
-			}
-			start := idx + k + len(dish)
-			window := review[start:]
-			if len(window) > 40 {
-				window = window[:40]
-			}
-			for _, t := range rbPositive {
-				if strings.Contains(window, t) && !contains(pos, t) {
-					pos = append(pos, t)
-				}
-			}
-			for _, t := range rbNegative {
-				if strings.Contains(window, t) && !contains(neg, t) {
-					neg = append(neg, t)
-				}
-			}
-			idx = start
-			if idx >= len(review) {
-				break
-			}
-		}
-		it := ExtractItem{Restaurant: restaurant, Dish: dish}
-		it.Sentiment.Positive = pos
-		it.Sentiment.Negative = neg
-		cuisine := "thai"
-		it.Cuisine = &cuisine
-		// restriction defaults to nil
-		items = append(items, it)
-	}
-	return items
+	return ruleBasedExtractThai(restaurant, review, lex)
 }
 
 func startsWithAny(s string, arr []string) bool {
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies provider failures so callers know whether retrying can help
+type ErrorKind string
+
+const (
+	KindRateLimited ErrorKind = "rate_limited" // 429; retry after RetryAfter
+	KindTransient   ErrorKind = "transient"    // 5xx, timeouts, connection errors; retry with backoff
+	KindPermanent   ErrorKind = "permanent"    // other 4xx (bad key, bad request); retrying will not help
+	KindMalformed   ErrorKind = "malformed"    // 2xx with a body that is not the expected envelope
+)
+
+// Error is returned by providers for failed calls
+type Error struct {
+	Kind       ErrorKind
+	Provider   string
+	StatusCode int           // 0 when no HTTP response was received
+	RetryAfter time.Duration // server-requested delay, 0 when not given
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("%s request failed (%s, HTTP %d): %v", e.Provider, e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s request failed (%s): %v", e.Provider, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Retryable reports whether the same request may succeed later
+func (e *Error) Retryable() bool {
+	return e.Kind == KindRateLimited || e.Kind == KindTransient
+}
+
+// KindOf returns the classification of err. Errors that did not come from a provider
+// (e.g. a fake fixture) count as transient; context cancellation is permanent.
+func KindOf(err error) ErrorKind {
+	var le *Error
+	if errors.As(err, &le) {
+		return le.Kind
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return KindPermanent
+	}
+	return KindTransient
+}
+
+// statusError classifies a non-2xx response; the body snippet helps explain 4xx failures
+func statusError(provider string, resp *http.Response, body []byte) *Error {
+	kind := KindPermanent
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		kind = KindRateLimited
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusRequestTimeout:
+		kind = KindTransient
+	}
+	msg := resp.Status
+	if snippet := strings.TrimSpace(string(body)); snippet != "" {
+		if len(snippet) > 200 {
+			snippet = snippet[:200]
+		}
+		msg += ": " + snippet
+	}
+	return &Error{
+		Kind:       kind,
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Err:        errors.New(msg),
+	}
+}
+
+// transportError wraps a failed round trip. Cancellation by the caller is returned as-is.
+func transportError(provider string, ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return &Error{Kind: KindTransient, Provider: provider, Err: err}
+}
+
+func malformedError(provider string, err error) *Error {
+	return &Error{Kind: KindMalformed, Provider: provider, Err: err}
+}
+
+// parseRetryAfter accepts delta-seconds or an HTTP date
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Extractor turns one review into dish items. Implementations must be safe for concurrent use.
+type Extractor interface {
+	Extract(ctx context.Context, restaurant, review string, hintDish string) (*ExtractResponse, error)
+	// Model and Prompt identify what answers requests; both are part of the cache key
+	Model() string
+	Prompt() Prompt
+}
+
+// Provider names accepted in Config.Provider
+const (
+	ProviderOpenAI = "openai" // OpenAI or any OpenAI-compatible server
+	ProviderOllama = "ollama" // Ollama's native /api/chat
+	ProviderFake   = "fake"   // fixture replay, no network
+)
+
+// Config selects and configures an Extractor. Empty fields use each provider's defaults.
+type Config struct {
+	Provider string
+	BaseURL  string
+	APIKey   string
+	Model    string
+	Timeout  time.Duration
+	Fixtures string // fixture file for the fake provider
+
+	PromptVersion string // extract_<version>.txt; default DefaultPromptVersion
+	PromptDir     string // optional directory whose prompt files override the embedded ones
+
+	// ReaskOnInvalid sends an answer that failed validation back to the model once
+	ReaskOnInvalid bool
+	// ResponseFormat is FormatJSONSchema (default; falls back automatically when unsupported)
+	// or FormatJSONObject
+	ResponseFormat string
+
+	Resilience ResilienceConfig
+	Cache      *Cache // nil disables caching; never applied to the fake provider
+	Meter      *Meter // nil disables usage accounting
+}
+
+// New builds the Extractor named by cfg.Provider (default openai). From the outside in, it is
+// wrapped with WithCache (when cfg.Cache is set), WithResilience and WithMetering (when cfg.Meter is set).
+func New(cfg Config) (Extractor, error) {
+	prompt, err := LoadPrompt(cfg.PromptVersion, cfg.PromptDir)
+	if err != nil {
+		return nil, err
+	}
+	format := strings.ToLower(strings.TrimSpace(cfg.ResponseFormat))
+	switch format {
+	case "":
+		format = FormatJSONSchema
+	case FormatJSONSchema, FormatJSONObject:
+	default:
+		return nil, fmt.Errorf("unknown llm response format %q (want %s or %s)", cfg.ResponseFormat, FormatJSONSchema, FormatJSONObject)
+	}
+	var provider Extractor
+	cacheable := true
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "", ProviderOpenAI:
+		client := NewClient(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.Timeout, prompt)
+		client.reask = cfg.ReaskOnInvalid
+		client.format = format
+		provider = client
+	case ProviderOllama:
+		client := NewOllamaClient(cfg.BaseURL, cfg.Model, cfg.Timeout, prompt)
+		client.reask = cfg.ReaskOnInvalid
+		client.format = format
+		provider = client
+	case ProviderFake:
+		fake, err := NewFakeFromFile(cfg.Fixtures)
+		if err != nil {
+			return nil, err
+		}
+		provider = fake
+		// Fixture edits must take effect immediately
+		cacheable = false
+	default:
+		return nil, fmt.Errorf("unknown llm provider %q (want %s, %s or %s)", cfg.Provider, ProviderOpenAI, ProviderOllama, ProviderFake)
+	}
+	if cfg.Meter != nil {
+		provider = WithMetering(provider, cfg.Meter)
+	}
+	ext := WithResilience(provider, cfg.Resilience)
+	if cfg.Cache != nil && cacheable {
+		ext = WithCache(ext, cfg.Cache)
+	}
+	return ext, nil
+}
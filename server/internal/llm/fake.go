@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FakeModel is reported as ExtractResponse.Model by the fake provider
+const FakeModel = "fake"
+
+// Fixture is one canned reply. Restaurant and HintDish are optional filters; Error makes
+// Extract fail so retry paths can be exercised.
+type Fixture struct {
+	Restaurant string        `json:"restaurant,omitempty"`
+	Review     string        `json:"review"`
+	HintDish   string        `json:"hint_dish,omitempty"`
+	Items      []ExtractItem `json:"items"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Fake replays fixtures without any network access. Reviews with no fixture get the
+// rule-based extraction, so results are deterministic for any input.
+type Fake struct {
+	fixtures []Fixture
+}
+
+// NewFake builds a fake backend from in-memory fixtures
+func NewFake(fixtures []Fixture) *Fake {
+	return &Fake{fixtures: fixtures}
+}
+
+// NewFakeFromFile loads a JSON array of fixtures; an empty path gives a fake with none
+func NewFakeFromFile(path string) (*Fake, error) {
+	if path == "" {
+		return NewFake(nil), nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read llm fixtures: %w", err)
+	}
+	var fixtures []Fixture
+	if err := json.Unmarshal(b, &fixtures); err != nil {
+		return nil, fmt.Errorf("parse llm fixtures %s: %w", path, err)
+	}
+	return NewFake(fixtures), nil
+}
+
+func (f *Fake) Model() string { return FakeModel }
+
+// Prompt is empty: fixtures do not depend on a prompt
+func (f *Fake) Prompt() Prompt { return Prompt{} }
+
+func (f *Fake) Extract(ctx context.Context, restaurant, review string, hintDish string) (*ExtractResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if fx := f.match(restaurant, review, hintDish); fx != nil {
+		if fx.Error != "" {
+			return nil, errors.New(fx.Error)
+		}
+		// Fixtures go through the same validation as real answers so warnings can be tested
+		items, warnings := ValidateItems(fx.Items, restaurant)
+		return &ExtractResponse{Items: items, Model: FakeModel, Warnings: warnings}, nil
+	}
+	return finishExtract(restaurant, withHint(review, hintDish), hintDish, "", FakeModel), nil
+}
+
+// match returns the first fixture whose review (and optional filters) equal the input
+func (f *Fake) match(restaurant, review, hintDish string) *Fixture {
+	review = strings.TrimSpace(review)
+	for i := range f.fixtures {
+		fx := &f.fixtures[i]
+		if strings.TrimSpace(fx.Review) != review {
+			continue
+		}
+		if fx.Restaurant != "" && fx.Restaurant != restaurant {
+			continue
+		}
+		if fx.HintDish != "" && fx.HintDish != hintDish {
+			continue
+		}
+		return fx
+	}
+	return nil
+}
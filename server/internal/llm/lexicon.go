@@ -0,0 +1,337 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/thai"
+)
+
+//go:embed lexicon.json
+var embeddedLexicon []byte
+
+// Lexicon holds the word lists used by the rule-based extraction and by validation
+type Lexicon struct {
+	IngredientRoots []string `json:"ingredient_roots"` // dish name starts; also the dish pattern
+	Methods         []string `json:"methods"`          // cooking methods that may follow a root
+	FlavorParts     []string `json:"flavor_parts"`     // flavourings that may follow a root
+	Positive        []string `json:"positive"`
+	Negative        []string `json:"negative"`
+	ForbiddenDishes []string `json:"forbidden_dishes"` // generic placeholders never kept as dishes
+	Words           []string `json:"words"`            // extra vocabulary for word segmentation only
+
+	// English is used for reviews written mostly in Latin script; nil disables English fallback
+	English *EnglishLexicon `json:"english,omitempty"`
+
+	Source string `json:"-"` // file path, or "embedded"
+
+	forbidden map[string]struct{}
+	dishRegex *regexp.Regexp
+}
+
+// EnglishLexicon holds the word lists for English reviews. Entries are matched case-insensitively
+// on whole words; only dish names may span several words.
+type EnglishLexicon struct {
+	Dishes   map[string]string `json:"dishes"`  // dish name -> cuisine ("" when it depends on the restaurant)
+	Methods  []string          `json:"methods"` // modifiers kept in front of a dish, e.g. "grilled"
+	Positive []string          `json:"positive"`
+	Negative []string          `json:"negative"`
+	Negators []string          `json:"negators"` // flip the polarity of a sentiment word up to 3 words later
+
+	dishes  map[string][]string // first word -> dish names starting with it, longest first
+	methods map[string]bool
+	polar   map[string]bool // sentiment word -> true when positive
+	negate  map[string]bool
+}
+
+// ParseLexicon decodes and validates a lexicon file
+func ParseLexicon(data []byte, source string) (*Lexicon, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var l Lexicon
+	if err := dec.Decode(&l); err != nil {
+		return nil, fmt.Errorf("lexicon %s: %w", source, err)
+	}
+	l.Source = source
+	if err := l.prepare(); err != nil {
+		return nil, fmt.Errorf("lexicon %s: %w", source, err)
+	}
+	return &l, nil
+}
+
+// LoadLexicon reads the override file at path, or the lexicon embedded in the binary (the only
+// copy kept in the repository) when path is empty. A configured path that cannot be read is an error.
+func LoadLexicon(path string) (*Lexicon, error) {
+	if path == "" {
+		return ParseLexicon(embeddedLexicon, "embedded")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("lexicon: %w", err)
+	}
+	return ParseLexicon(data, path)
+}
+
+// prepare trims entries, checks the lists and builds the lookup set and dish pattern
+func (l *Lexicon) prepare() error {
+	var problems []string
+	lists := []struct {
+		name     string
+		values   *[]string
+		required bool
+	}{
+		{"ingredient_roots", &l.IngredientRoots, true},
+		{"methods", &l.Methods, false},
+		{"flavor_parts", &l.FlavorParts, false},
+		{"positive", &l.Positive, true},
+		{"negative", &l.Negative, true},
+		{"forbidden_dishes", &l.ForbiddenDishes, false},
+		{"words", &l.Words, false},
+	}
+	for _, list := range lists {
+		seen := map[string]bool{}
+		out := make([]string, 0, len(*list.values))
+		for i, v := range *list.values {
+			v = strings.TrimSpace(v)
+			switch {
+			case v == "":
+				problems = append(problems, fmt.Sprintf("%s[%d] is empty", list.name, i))
+			case seen[v]:
+				problems = append(problems, fmt.Sprintf("%s has %q twice", list.name, v))
+			default:
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+		if list.required && len(out) == 0 {
+			problems = append(problems, list.name+" must not be empty")
+		}
+		*list.values = out
+	}
+
+	l.forbidden = make(map[string]struct{}, len(l.ForbiddenDishes))
+	for _, d := range l.ForbiddenDishes {
+		l.forbidden[d] = struct{}{}
+	}
+	for _, root := range l.IngredientRoots {
+		if _, bad := l.forbidden[root]; bad {
+			problems = append(problems, fmt.Sprintf("ingredient root %q is also a forbidden dish", root))
+		}
+	}
+	for _, p := range l.Positive {
+		if contains(l.Negative, p) {
+			problems = append(problems, fmt.Sprintf("%q is both positive and negative", p))
+		}
+	}
+	if l.English != nil {
+		problems = append(problems, l.English.prepare()...)
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	// Longest roots first so the alternation prefers e.g. ผัดไทย over ผัด
+	roots := append([]string(nil), l.IngredientRoots...)
+	sort.SliceStable(roots, func(i, j int) bool { return len(roots[i]) > len(roots[j]) })
+	for i, r := range roots {
+		roots[i] = regexp.QuoteMeta(r)
+	}
+	re, err := regexp.Compile("(" + strings.Join(roots, "|") + ")")
+	if err != nil {
+		return fmt.Errorf("dish pattern: %w", err)
+	}
+	l.dishRegex = re
+	return nil
+}
+
+// prepare lowercases the English lists, builds the lookup tables and returns any problems
+func (e *EnglishLexicon) prepare() []string {
+	var problems []string
+	lower := func(name string, values []string) []string {
+		seen := map[string]bool{}
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			v = strings.Join(strings.Fields(normalizeApostrophes(strings.ToLower(v))), " ")
+			switch {
+			case v == "":
+				problems = append(problems, "english."+name+" has an empty entry")
+			case seen[v]:
+				problems = append(problems, fmt.Sprintf("english.%s has %q twice", name, v))
+			default:
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	e.Methods = lower("methods", e.Methods)
+	e.Positive = lower("positive", e.Positive)
+	e.Negative = lower("negative", e.Negative)
+	e.Negators = lower("negators", e.Negators)
+
+	dishes := make(map[string]string, len(e.Dishes))
+	for name, cuisine := range e.Dishes {
+		key := strings.Join(strings.Fields(strings.ToLower(name)), " ")
+		cuisine = strings.ToLower(strings.TrimSpace(cuisine))
+		switch {
+		case key == "":
+			problems = append(problems, "english.dishes has an empty name")
+			continue
+		case cuisine != "" && !contains(allowedCuisines, cuisine):
+			problems = append(problems, fmt.Sprintf("english.dishes[%q] has unknown cuisine %q", name, cuisine))
+		}
+		if _, dup := dishes[key]; dup {
+			problems = append(problems, fmt.Sprintf("english.dishes has %q twice", key))
+		}
+		dishes[key] = cuisine
+	}
+	e.Dishes = dishes
+	if len(e.Dishes) == 0 {
+		problems = append(problems, "english.dishes must not be empty")
+	}
+	if len(e.Positive) == 0 || len(e.Negative) == 0 {
+		problems = append(problems, "english.positive and english.negative must not be empty")
+	}
+
+	e.dishes = map[string][]string{}
+	for name := range e.Dishes {
+		first, _, _ := strings.Cut(name, " ")
+		e.dishes[first] = append(e.dishes[first], name)
+	}
+	for _, names := range e.dishes {
+		sort.Slice(names, func(i, j int) bool {
+			if len(names[i]) != len(names[j]) {
+				return len(names[i]) > len(names[j])
+			}
+			return names[i] < names[j]
+		})
+	}
+	e.methods = map[string]bool{}
+	for _, m := range e.Methods {
+		e.methods[m] = true
+	}
+	e.polar = map[string]bool{}
+	for _, p := range e.Positive {
+		e.polar[p] = true
+	}
+	for _, n := range e.Negative {
+		if e.polar[n] {
+			problems = append(problems, fmt.Sprintf("english %q is both positive and negative", n))
+		}
+		e.polar[n] = false
+	}
+	for _, m := range e.Methods {
+		if _, ok := e.polar[m]; ok {
+			problems = append(problems, fmt.Sprintf("english %q is both a method and a sentiment word", m))
+		}
+	}
+	e.negate = map[string]bool{}
+	for _, n := range e.Negators {
+		e.negate[n] = true
+	}
+	return problems
+}
+
+// IsForbiddenDish reports whether name is a generic placeholder rather than a dish
+func (l *Lexicon) IsForbiddenDish(name string) bool {
+	_, bad := l.forbidden[name]
+	return bad
+}
+
+// MentionsDish reports whether text contains any ingredient root
+func (l *Lexicon) MentionsDish(text string) bool {
+	return l.dishRegex.FindStringIndex(text) != nil
+}
+
+var (
+	activeLexicon atomic.Pointer[Lexicon]
+	// dictionaryWords are dish and keyword names from the database, added to the lexicon's
+	// words when building the segmentation dictionary
+	dictionaryWords atomic.Pointer[[]string]
+)
+
+func init() {
+	l, err := ParseLexicon(embeddedLexicon, "embedded")
+	if err != nil {
+		panic(err)
+	}
+	UseLexicon(l)
+}
+
+// CurrentLexicon returns the lexicon in use
+func CurrentLexicon() *Lexicon {
+	return activeLexicon.Load()
+}
+
+// UseLexicon replaces the lexicon for all subsequent extractions and rebuilds the shared
+// Thai segmentation dictionary
+func UseLexicon(l *Lexicon) {
+	activeLexicon.Store(l)
+	rebuildDictionary()
+}
+
+// SetDictionaryWords sets the database vocabulary (dish names, keywords and their aliases)
+// used for Thai segmentation alongside the lexicon
+func SetDictionaryWords(words []string) {
+	dictionaryWords.Store(&words)
+	rebuildDictionary()
+}
+
+func rebuildDictionary() {
+	l := activeLexicon.Load()
+	if l == nil {
+		return
+	}
+	var extra []string
+	if p := dictionaryWords.Load(); p != nil {
+		extra = *p
+	}
+	thai.Use(thai.NewDictionary(l.IngredientRoots, l.Methods, l.FlavorParts, l.Positive, l.Negative, l.ForbiddenDishes, l.Words, extra))
+}
+
+// WatchLexicon polls path every interval and switches to it when its modification time changes.
+// An invalid file is logged and the previous lexicon stays in use. It returns when ctx is done.
+func WatchLexicon(ctx context.Context, path string, interval time.Duration) {
+	if path == "" || interval <= 0 {
+		return
+	}
+	var last time.Time
+	if info, err := os.Stat(path); err == nil {
+		last = info.ModTime()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Equal(last) {
+			continue
+		}
+		last = info.ModTime()
+		l, err := LoadLexicon(path)
+		if err != nil {
+			log.Printf("[llm] lexicon reload rejected, keeping the previous one: %v", err)
+			continue
+		}
+		UseLexicon(l)
+		log.Printf("[llm] lexicon reloaded from %s (%d ingredient roots)", path, len(l.IngredientRoots))
+	}
+}
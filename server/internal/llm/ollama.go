@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultOllamaBaseURL is where `ollama serve` listens by default
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaClient talks to Ollama's native chat API with JSON mode enabled
+type OllamaClient struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+	prompt     Prompt
+	reask      bool   // set by New from Config.ReaskOnInvalid
+	format     string // FormatJSONSchema or FormatJSONObject
+
+	schemaOff schemaFallback // the server rejected a schema format; use plain JSON for a while
+}
+
+// NewOllamaClient builds a local backend; an empty baseURL uses DefaultOllamaBaseURL
+func NewOllamaClient(baseURL, model string, timeout time.Duration, prompt Prompt) *OllamaClient {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	if model == "" {
+		model = "llama3.1"
+	}
+	// Local models on CPU are slow; allow more time than the hosted API
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	return &OllamaClient{
+		httpClient: &http.Client{Timeout: timeout},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		prompt:     prompt,
+		format:     FormatJSONSchema,
+	}
+}
+
+type ollamaChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream"`
+	Format   any       `json:"format,omitempty"` // "json" or a JSON Schema
+}
+
+type ollamaChatResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+func (c *OllamaClient) Model() string { return c.model }
+
+func (c *OllamaClient) Prompt() Prompt { return c.prompt }
+
+func (c *OllamaClient) Extract(ctx context.Context, restaurant, review string, hintDish string) (*ExtractResponse, error) {
+	return extractWithChat(ctx, c.chat, c.model, c.prompt, c.reask, restaurant, review, hintDish)
+}
+
+// chat asks for schema-constrained output (Ollama 0.5+) and falls back to plain JSON mode
+func (c *OllamaClient) chat(ctx context.Context, messages []Message) (chatReply, error) {
+	format := c.format
+	if format == FormatJSONSchema && c.schemaOff.active() {
+		format = FormatJSONObject
+	}
+	reply, err := c.send(ctx, messages, format)
+	// Ollama names the schema in its "format" field; older servers answer "invalid format"
+	if err != nil && format == FormatJSONSchema && schemaRejected(err, "invalid format", "json schema") {
+		c.schemaOff.start()
+		log.Printf("[llm] %s rejected a JSON schema format, using plain JSON for %s: %v", c.baseURL, schemaRetryAfter, err)
+		reply, err = c.send(ctx, messages, FormatJSONObject)
+	}
+	reply.Degraded = reply.Format != c.format
+	return reply, err
+}
+
+// send makes one non-streaming /api/chat request
+func (c *OllamaClient) send(ctx context.Context, messages []Message, format string) (chatReply, error) {
+	req := ollamaChatRequest{Model: c.model, Messages: messages, Format: "json"}
+	if format == FormatJSONSchema {
+		req.Format = extractionSchema
+	}
+	data, _ := json.Marshal(req)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/chat", bytes.NewReader(data))
+	if err != nil {
+		return chatReply{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return chatReply{}, transportError("ollama", ctx, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return chatReply{}, statusError("ollama", resp, body)
+	}
+	var ch ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ch); err != nil {
+		return chatReply{}, malformedError("ollama", err)
+	}
+	return chatReply{
+		Content: ch.Message.Content,
+		Usage:   Usage{PromptTokens: ch.PromptEvalCount, CompletionTokens: ch.EvalCount},
+		Format:  format,
+	}, nil
+}
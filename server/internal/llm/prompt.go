@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultPromptVersion is the extraction prompt used when llm.promptVersion is unset
+const DefaultPromptVersion = "v1"
+
+//go:embed prompts/*.txt
+var embeddedPrompts embed.FS
+
+// Prompt is a versioned system prompt. Hash covers the text, so an edited override file is
+// never confused with the shipped prompt of the same version.
+type Prompt struct {
+	Version string
+	Text    string
+	Hash    string
+}
+
+// CacheID identifies the prompt in cache keys
+func (p Prompt) CacheID() string {
+	if p.Hash == "" {
+		return p.Version
+	}
+	return p.Version + "@" + p.Hash[:12]
+}
+
+var promptVersionPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// LoadPrompt reads extract_<version>.txt from dir when present, else from the prompts
+// embedded in the binary
+func LoadPrompt(version, dir string) (Prompt, error) {
+	if version == "" {
+		version = DefaultPromptVersion
+	}
+	if !promptVersionPattern.MatchString(version) {
+		return Prompt{}, fmt.Errorf("invalid prompt version %q", version)
+	}
+	name := "extract_" + version + ".txt"
+
+	var data []byte
+	var err error
+	if dir != "" {
+		data, err = os.ReadFile(filepath.Join(dir, name))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return Prompt{}, err
+		}
+	}
+	if data == nil {
+		data, err = embeddedPrompts.ReadFile("prompts/" + name)
+		if err != nil {
+			return Prompt{}, fmt.Errorf("prompt version %q not found", version)
+		}
+	}
+
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return Prompt{}, fmt.Errorf("prompt %s is empty", name)
+	}
+	sum := sha256.Sum256([]byte(text))
+	return Prompt{Version: version, Text: text, Hash: hex.EncodeToString(sum[:])}, nil
+}
@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// ResilienceConfig bounds and retries provider calls. Zero fields use the defaults below;
+// a negative MaxRetries or BreakerThreshold disables that feature.
+type ResilienceConfig struct {
+	MaxRetries       int           // retries after the first attempt
+	RetryBase        time.Duration // first backoff, doubled per retry
+	RetryMax         time.Duration // cap for backoff and for honoured Retry-After
+	MaxConcurrent    int           // provider calls in flight across the process
+	BreakerThreshold int           // consecutive failures that open the breaker
+	BreakerCooldown  time.Duration // how long the breaker stays open before probing
+}
+
+func (c ResilienceConfig) withDefaults() ResilienceConfig {
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	}
+	if c.RetryBase <= 0 {
+		c.RetryBase = 500 * time.Millisecond
+	}
+	if c.RetryMax <= 0 {
+		c.RetryMax = 20 * time.Second
+	}
+	if c.MaxConcurrent <= 0 {
+		c.MaxConcurrent = 4
+	}
+	if c.BreakerThreshold == 0 {
+		c.BreakerThreshold = 5
+	}
+	if c.BreakerCooldown <= 0 {
+		c.BreakerCooldown = time.Minute
+	}
+	return c
+}
+
+// resilientExtractor wraps a provider with a concurrency limit, retries and a circuit breaker.
+// While the breaker is open reviews get the rule-based extraction without calling the provider.
+type resilientExtractor struct {
+	inner   Extractor
+	cfg     ResilienceConfig
+	sem     chan struct{}
+	breaker *breaker
+}
+
+// WithResilience wraps inner; New applies it to every provider
+func WithResilience(inner Extractor, cfg ResilienceConfig) Extractor {
+	cfg = cfg.withDefaults()
+	return &resilientExtractor{
+		inner:   inner,
+		cfg:     cfg,
+		sem:     make(chan struct{}, cfg.MaxConcurrent),
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+}
+
+func (r *resilientExtractor) Model() string { return r.inner.Model() }
+
+func (r *resilientExtractor) Prompt() Prompt { return r.inner.Prompt() }
+
+func (r *resilientExtractor) Extract(ctx context.Context, restaurant, review string, hintDish string) (*ExtractResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if !r.breaker.allow(time.Now()) {
+			return fallbackExtract(restaurant, review, hintDish), nil
+		}
+		out, err := r.call(ctx, restaurant, review, hintDish)
+		if err == nil {
+			if r.breaker.success() {
+				log.Printf("[llm] circuit closed, provider recovered")
+			}
+			return out, nil
+		}
+		if ctx.Err() != nil {
+			r.breaker.release()
+			return nil, ctx.Err()
+		}
+		kind := KindOf(err)
+		if kind == KindPermanent {
+			r.breaker.release()
+			return nil, err
+		}
+		if r.breaker.failure(time.Now()) {
+			log.Printf("[llm] circuit opened for %s after: %v", r.cfg.BreakerCooldown, err)
+		}
+		if kind == KindMalformed || attempt >= r.cfg.MaxRetries {
+			return nil, err
+		}
+		wait := r.backoff(attempt, err)
+		// Give up now rather than sleep past the caller's deadline; the job queue retries later
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// call runs one provider attempt while holding a concurrency slot
+func (r *resilientExtractor) call(ctx context.Context, restaurant, review string, hintDish string) (*ExtractResponse, error) {
+	select {
+	case r.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-r.sem }()
+	return r.inner.Extract(ctx, restaurant, review, hintDish)
+}
+
+// backoff honours Retry-After when given, else uses full-jitter exponential backoff
+func (r *resilientExtractor) backoff(attempt int, err error) time.Duration {
+	var le *Error
+	if errors.As(err, &le) && le.RetryAfter > 0 {
+		if le.RetryAfter > r.cfg.RetryMax {
+			return r.cfg.RetryMax
+		}
+		return le.RetryAfter
+	}
+	d := r.cfg.RetryBase << attempt
+	if d <= 0 || d > r.cfg.RetryMax {
+		d = r.cfg.RetryMax
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// fallbackExtract is the rule-based result used when no provider call is made
+func fallbackExtract(restaurant, review, hintDish string) *ExtractResponse {
+	return finishExtract(restaurant, withHint(review, hintDish), hintDish, "", "")
+}
@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scripts reported by detectScript
+const (
+	scriptThai  = "thai"
+	scriptLatin = "latin"
+)
+
+// detectScript returns the script most of the review's letters are written in
+func detectScript(text string) string {
+	thai, latin := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	if latin > thai {
+		return scriptLatin
+	}
+	return scriptThai
+}
+
+// negationScope is how many words after a negator it still applies to ("not too salty")
+const negationScope = 3
+
+// contrastWords end a negation's scope ("not fancy but delicious")
+var contrastWords = map[string]bool{"but": true, "though": true, "although": true, "however": true}
+
+func normalizeApostrophes(s string) string {
+	return strings.NewReplacer("’", "'", "‘", "'").Replace(s)
+}
+
+// englishSentences lowercases the review and splits it into sentences of words
+func englishSentences(review string) [][]string {
+	text := normalizeApostrophes(strings.ToLower(review))
+	var sentences [][]string
+	for _, s := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == '.' || r == '!' || r == '?' || r == ';' || r == '\n'
+	}) {
+		words := strings.FieldsFunc(s, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+		})
+		for i, w := range words {
+			words[i] = strings.Trim(w, "'-")
+		}
+		if len(words) > 0 {
+			sentences = append(sentences, words)
+		}
+	}
+	return sentences
+}
+
+// matchDish returns the dish name starting at words[i] and how many words it spans.
+// Plural forms ("dumpling" vs "dumplings") match either way.
+func (e *EnglishLexicon) matchDish(words []string, i int) (string, int) {
+	for _, first := range wordForms(words[i]) {
+		for _, name := range e.dishes[first] {
+			parts := strings.Split(name, " ")
+			if i+len(parts) > len(words) {
+				continue
+			}
+			ok := true
+			for k := 1; k < len(parts); k++ {
+				if !contains(wordForms(words[i+k]), parts[k]) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				return name, len(parts)
+			}
+		}
+	}
+	return "", 0
+}
+
+// wordForms is w with its plural suffix added or removed
+func wordForms(w string) []string {
+	forms := []string{w, w + "s"}
+	if strings.HasSuffix(w, "es") {
+		forms = append(forms, strings.TrimSuffix(w, "es"))
+	}
+	if strings.HasSuffix(w, "s") {
+		forms = append(forms, strings.TrimSuffix(w, "s"))
+	}
+	return forms
+}
+
+// englishMention is one dish found in a sentence; words[start:end] covers its methods and name
+type englishMention struct {
+	dish       string
+	cuisine    string
+	start, end int
+}
+
+// ruleBasedExtractEnglish finds known dishes (with their cooking methods) and attaches the
+// sentiment words of the same sentence. A negated word goes to the opposite list as a phrase,
+// so "not salty" is positive and "not fresh" negative.
+func ruleBasedExtractEnglish(restaurant, review string, lex *Lexicon) []ExtractItem {
+	e := lex.English
+	if e == nil {
+		return nil
+	}
+	byDish := map[string]*ExtractItem{}
+	var order []string
+	for _, words := range englishSentences(review) {
+		var mentions []englishMention
+		for i := 0; i < len(words); {
+			name, n := e.matchDish(words, i)
+			if n == 0 {
+				i++
+				continue
+			}
+			start := i
+			for start > 0 && e.methods[words[start-1]] {
+				start--
+			}
+			dish := strings.Join(append(append([]string(nil), words[start:i]...), name), " ")
+			if !lex.IsForbiddenDish(dish) {
+				mentions = append(mentions, englishMention{dish: dish, cuisine: e.Dishes[name], start: start, end: i + n})
+			}
+			i += n
+		}
+
+		for m, mention := range mentions {
+			// Sentiment after the dish up to the next dish; if there is none, before it
+			// ("delicious pad thai") back to the previous dish
+			from, to := mention.end, len(words)
+			if m+1 < len(mentions) {
+				to = mentions[m+1].start
+			}
+			pos, neg := e.sentiment(words, from, to)
+			if len(pos) == 0 && len(neg) == 0 {
+				from, to = 0, mention.start
+				if m > 0 {
+					from = mentions[m-1].end
+				}
+				pos, neg = e.sentiment(words, from, to)
+			}
+
+			it := byDish[mention.dish]
+			if it == nil {
+				it = &ExtractItem{Restaurant: restaurant, Dish: mention.dish}
+				it.Sentiment.Positive = []string{}
+				it.Sentiment.Negative = []string{}
+				if mention.cuisine != "" {
+					cuisine := mention.cuisine
+					it.Cuisine = &cuisine
+				}
+				byDish[mention.dish] = it
+				order = append(order, mention.dish)
+			}
+			for _, t := range pos {
+				if !contains(it.Sentiment.Positive, t) {
+					it.Sentiment.Positive = append(it.Sentiment.Positive, t)
+				}
+			}
+			for _, t := range neg {
+				if !contains(it.Sentiment.Negative, t) {
+					it.Sentiment.Negative = append(it.Sentiment.Negative, t)
+				}
+			}
+		}
+	}
+
+	items := make([]ExtractItem, 0, len(order))
+	for _, dish := range order {
+		items = append(items, *byDish[dish])
+	}
+	return items
+}
+
+// sentiment collects the sentiment words in words[from:to], applying negation
+func (e *EnglishLexicon) sentiment(words []string, from, to int) (pos, neg []string) {
+	negatedUntil := -1
+	negator := ""
+	for i := from; i < to; i++ {
+		w := words[i]
+		switch {
+		case e.negate[w]:
+			negatedUntil, negator = i+negationScope, w
+			continue
+		case contrastWords[w]:
+			negatedUntil = -1
+			continue
+		}
+		positive, ok := e.polar[w]
+		if !ok {
+			continue
+		}
+		token := w
+		if i <= negatedUntil {
+			token = negator + " " + w
+			positive = !positive
+			negatedUntil = -1
+		}
+		if positive {
+			pos = append(pos, token)
+		} else {
+			neg = append(neg, token)
+		}
+	}
+	return pos, neg
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/bestchayapol/DishDive/internal/thai"
+)
+
+// Limits of the Thai rule-based extraction, in segmented words and runes
+const (
+	thaiMaxDishParts       = 3  // method and flavour words appended to an ingredient root
+	thaiSentimentWindow    = 40 // runes after a dish searched for sentiment words
+	thaiSentimentMaxTokens = 8
+)
+
+// thaiMention is one dish found in the segmented review; tokens[start:end] spell it
+type thaiMention struct {
+	dish       string
+	start, end int
+}
+
+// ruleBasedExtractThai segments the review into words, takes each word starting with an
+// ingredient root (plus following method and flavour words) as a dish, and attaches the
+// sentiment words that follow it within a short rune-counted window.
+func ruleBasedExtractThai(restaurant, review string, lex *Lexicon) []ExtractItem {
+	runes := []rune(review)
+	tokens := thai.Current().Segment(review)
+	// joined reports whether only spaces separate tokens[i-1] and tokens[i]
+	joined := func(i int) bool {
+		for _, r := range runes[tokens[i-1].End:tokens[i].Start] {
+			if !unicode.IsSpace(r) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var mentions []thaiMention
+	for i := 0; i < len(tokens); i++ {
+		if !startsWithAny(tokens[i].Text, lex.IngredientRoots) {
+			continue
+		}
+		parts := []string{tokens[i].Text}
+		end := i + 1
+		for end < len(tokens) && len(parts) <= thaiMaxDishParts && joined(end) {
+			t := tokens[end].Text
+			if !startsWithAny(t, lex.Methods) && !startsWithAny(t, lex.FlavorParts) {
+				break
+			}
+			parts = append(parts, t)
+			end++
+		}
+		dish := strings.Join(parts, "")
+		// A sentiment word glued to the dish by an unknown-word run ("ปลาเผาอร่อยๆ")
+		for _, s := range lex.Positive {
+			dish = strings.TrimSuffix(dish, s)
+		}
+		for _, s := range lex.Negative {
+			dish = strings.TrimSuffix(dish, s)
+		}
+		if dish != "" && !lex.IsForbiddenDish(dish) {
+			mentions = append(mentions, thaiMention{dish: dish, start: i, end: end})
+		}
+		i = end - 1
+	}
+	if len(mentions) == 0 {
+		// Roots inside longer unknown words
+		for i, t := range tokens {
+			for _, root := range lex.IngredientRoots {
+				if strings.Contains(t.Text, root) && !lex.IsForbiddenDish(root) {
+					mentions = append(mentions, thaiMention{dish: root, start: i, end: i + 1})
+					break
+				}
+			}
+		}
+	}
+
+	byDish := map[string]*ExtractItem{}
+	var order []string
+	for m, mention := range mentions {
+		it := byDish[mention.dish]
+		if it == nil {
+			it = &ExtractItem{Restaurant: restaurant, Dish: mention.dish}
+			it.Sentiment.Positive = []string{}
+			it.Sentiment.Negative = []string{}
+			cuisine := "thai"
+			it.Cuisine = &cuisine
+			byDish[mention.dish] = it
+			order = append(order, mention.dish)
+		}
+
+		stop := len(tokens)
+		if m+1 < len(mentions) && mentions[m+1].start > mention.end {
+			stop = mentions[m+1].start
+		}
+		dishEnd := tokens[mention.end-1].End
+		for k := mention.end; k < stop && k < mention.end+thaiSentimentMaxTokens; k++ {
+			if tokens[k].Start-dishEnd > thaiSentimentWindow {
+				break
+			}
+			w := tokens[k].Text
+			if contains(lex.Positive, w) && !contains(it.Sentiment.Positive, w) {
+				it.Sentiment.Positive = append(it.Sentiment.Positive, w)
+			}
+			if contains(lex.Negative, w) && !contains(it.Sentiment.Negative, w) {
+				it.Sentiment.Negative = append(it.Sentiment.Negative, w)
+			}
+		}
+	}
+
+	items := make([]ExtractItem, 0, len(order))
+	for _, dish := range order {
+		items = append(items, *byDish[dish])
+	}
+	return items
+}
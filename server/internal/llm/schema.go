@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Response formats requested from providers
+const (
+	FormatJSONSchema = "json_schema" // structured output constrained by extractionSchema
+	FormatJSONObject = "json_object" // any JSON object; parsed defensively
+)
+
+// schemaEnums restricts string fields of ExtractItem (by JSON name) to the prompt's values
+var schemaEnums = map[string][]string{
+	"cuisine":     allowedCuisines,
+	"restriction": allowedRestrict,
+}
+
+// extractionSchema is the JSON Schema of {"items": [ExtractItem]}, in the strict subset
+// accepted by OpenAI structured outputs: every property required, no additional properties
+var extractionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"items": map[string]any{
+			"type":  "array",
+			"items": schemaFor(reflect.TypeOf(ExtractItem{}), ""),
+		},
+	},
+	"required":             []string{"items"},
+	"additionalProperties": false,
+}
+
+// schemaFor derives a JSON Schema from a Go type using its json tags. Pointers become nullable.
+func schemaFor(t reflect.Type, name string) map[string]any {
+	nullable := false
+	if t.Kind() == reflect.Pointer {
+		nullable = true
+		t = t.Elem()
+	}
+	var s map[string]any
+	switch t.Kind() {
+	case reflect.Struct:
+		props := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || jsonName == "-" {
+				continue
+			}
+			if jsonName == "" {
+				jsonName = f.Name
+			}
+			props[jsonName] = schemaFor(f.Type, jsonName)
+			required = append(required, jsonName)
+		}
+		s = map[string]any{"type": "object", "properties": props, "required": required, "additionalProperties": false}
+	case reflect.Slice, reflect.Array:
+		s = map[string]any{"type": "array", "items": schemaFor(t.Elem(), "")}
+	case reflect.String:
+		s = map[string]any{"type": "string"}
+		if values, ok := schemaEnums[name]; ok {
+			enum := make([]any, 0, len(values)+1)
+			for _, v := range values {
+				enum = append(enum, v)
+			}
+			if nullable {
+				enum = append(enum, nil)
+			}
+			s["enum"] = enum
+		}
+	case reflect.Bool:
+		s = map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		s = map[string]any{"type": "number"}
+	default:
+		s = map[string]any{}
+	}
+	if nullable {
+		s["type"] = []any{s["type"], "null"}
+	}
+	return s
+}
+
+// schemaRetryAfter is how long a provider that rejected schema output is sent the weaker format
+// before schema output is tried again (the server may have been upgraded or the error a fluke)
+const schemaRetryAfter = 15 * time.Minute
+
+// schemaFallback remembers until when a provider is asked for FormatJSONObject instead of
+// FormatJSONSchema; the zero value asks for schema output
+type schemaFallback struct {
+	until atomic.Int64 // unix nanoseconds
+}
+
+func (f *schemaFallback) active() bool {
+	return time.Now().UnixNano() < f.until.Load()
+}
+
+func (f *schemaFallback) start() {
+	f.until.Store(time.Now().Add(schemaRetryAfter).UnixNano())
+}
+
+// schemaRejected reports whether a provider refused the request because it does not support
+// schema-constrained output (as opposed to any other bad request): a 400 whose error names one
+// of the request fields that carry the schema
+func schemaRejected(err error, fields ...string) bool {
+	var le *Error
+	if !errors.As(err, &le) || le.StatusCode != 400 {
+		return false
+	}
+	msg := strings.ToLower(le.Err.Error())
+	for _, f := range fields {
+		if strings.Contains(msg, f) {
+			return true
+		}
+	}
+	return false
+}
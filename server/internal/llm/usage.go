@@ -0,0 +1,248 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage is the token count reported by the provider for one call
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Price is the cost of a model in USD per million tokens
+type Price struct {
+	Prompt     float64 `mapstructure:"prompt"`
+	Completion float64 `mapstructure:"completion"`
+}
+
+// Cost of usage at this price
+func (p Price) Cost(u Usage) float64 {
+	return (float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6
+}
+
+// OutcomeOK marks a successful call; failed calls use their ErrorKind
+const OutcomeOK = "ok"
+
+// CallRecord is one provider call attributed to the review being processed
+type CallRecord struct {
+	SourceID         uint
+	SourceType       string
+	Model            string
+	Outcome          string
+	ResponseFormat   string // FormatJSONSchema or FormatJSONObject; empty for failed calls
+	UsedFallback     bool   // the answer could not be used and rule-based extraction ran instead
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+	CostUSD          float64
+	At               time.Time
+}
+
+// UsageRecorder persists call records; implemented in the repository package
+type UsageRecorder interface {
+	RecordLLMCall(ctx context.Context, call CallRecord) error
+	// SumLLMCostSince is used to restore today's spend after a restart
+	SumLLMCostSince(since time.Time) (float64, error)
+}
+
+type sourceKey struct{}
+
+type source struct {
+	id  uint
+	typ string
+}
+
+// WithSource tags ctx with the review being extracted so its provider calls are attributed to it
+func WithSource(ctx context.Context, sourceType string, sourceID uint) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source{id: sourceID, typ: sourceType})
+}
+
+// MeterConfig prices calls and caps daily spend. Pricing keys are matched case-insensitively;
+// models missing from it cost 0.
+type MeterConfig struct {
+	Pricing        map[string]Price
+	DailyBudgetUSD float64 // 0 disables the budget
+}
+
+// ModelTotals are cumulative counters for one model since process start
+type ModelTotals struct {
+	Model            string
+	Calls            uint64
+	Errors           uint64
+	SchemaCalls      uint64 // answered under a JSON schema
+	Fallbacks        uint64 // answers replaced by rule-based extraction
+	PromptTokens     uint64
+	CompletionTokens uint64
+	CostUSD          float64
+	LatencySeconds   float64
+}
+
+// MeterSnapshot is a point-in-time view of the meter
+type MeterSnapshot struct {
+	Models         []ModelTotals
+	SpentTodayUSD  float64
+	DailyBudgetUSD float64
+	Paused         bool
+}
+
+// Meter records provider calls, keeps per-model totals and tracks today's spend against the budget
+type Meter struct {
+	cfg      MeterConfig
+	recorder UsageRecorder
+
+	mu         sync.Mutex
+	day        time.Time
+	spentToday float64
+	totals     map[string]*ModelTotals
+}
+
+// NewMeter restores today's spend from recorder (may be nil)
+func NewMeter(cfg MeterConfig, recorder UsageRecorder) (*Meter, error) {
+	pricing := make(map[string]Price, len(cfg.Pricing))
+	for model, p := range cfg.Pricing {
+		pricing[strings.ToLower(model)] = p
+	}
+	cfg.Pricing = pricing
+	m := &Meter{cfg: cfg, recorder: recorder, totals: make(map[string]*ModelTotals)}
+	m.day = startOfDay(time.Now())
+	if recorder != nil {
+		spent, err := recorder.SumLLMCostSince(m.day)
+		if err != nil {
+			return nil, fmt.Errorf("load today's LLM spend: %w", err)
+		}
+		m.spentToday = spent
+	}
+	return m, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, t.Location())
+}
+
+// rollover resets today's spend at local midnight; callers hold mu
+func (m *Meter) rollover(now time.Time) {
+	if day := startOfDay(now); day.After(m.day) {
+		m.day = day
+		m.spentToday = 0
+	}
+}
+
+func (m *Meter) record(ctx context.Context, call CallRecord) {
+	if price, ok := m.cfg.Pricing[strings.ToLower(call.Model)]; ok {
+		call.CostUSD = price.Cost(Usage{PromptTokens: call.PromptTokens, CompletionTokens: call.CompletionTokens})
+	}
+	if src, ok := ctx.Value(sourceKey{}).(source); ok {
+		call.SourceID, call.SourceType = src.id, src.typ
+	}
+
+	m.mu.Lock()
+	m.rollover(call.At)
+	m.spentToday += call.CostUSD
+	t := m.totals[call.Model]
+	if t == nil {
+		t = &ModelTotals{Model: call.Model}
+		m.totals[call.Model] = t
+	}
+	t.Calls++
+	if call.Outcome != OutcomeOK {
+		t.Errors++
+	}
+	if call.ResponseFormat == FormatJSONSchema {
+		t.SchemaCalls++
+	}
+	if call.UsedFallback {
+		t.Fallbacks++
+	}
+	t.PromptTokens += uint64(call.PromptTokens)
+	t.CompletionTokens += uint64(call.CompletionTokens)
+	t.CostUSD += call.CostUSD
+	t.LatencySeconds += call.Latency.Seconds()
+	m.mu.Unlock()
+
+	if m.recorder != nil {
+		// Record even if the job's context has been cancelled; the call already happened
+		if err := m.recorder.RecordLLMCall(context.WithoutCancel(ctx), call); err != nil {
+			log.Printf("[llm] could not record call usage: %v", err)
+		}
+	}
+}
+
+// OverBudget reports whether today's spend has reached the daily budget
+func (m *Meter) OverBudget(now time.Time) bool {
+	if m == nil || m.cfg.DailyBudgetUSD <= 0 {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollover(now)
+	return m.spentToday >= m.cfg.DailyBudgetUSD
+}
+
+// Paused lets the review queue stop claiming jobs while the budget is exhausted
+func (m *Meter) Paused(now time.Time) (bool, string) {
+	if m.OverBudget(now) {
+		return true, fmt.Sprintf("daily LLM budget of $%.2f reached", m.cfg.DailyBudgetUSD)
+	}
+	return false, ""
+}
+
+// Snapshot returns per-model totals sorted by model name; a nil meter reports zeros
+func (m *Meter) Snapshot() MeterSnapshot {
+	if m == nil {
+		return MeterSnapshot{}
+	}
+	now := time.Now()
+	m.mu.Lock()
+	m.rollover(now)
+	snap := MeterSnapshot{SpentTodayUSD: m.spentToday, DailyBudgetUSD: m.cfg.DailyBudgetUSD}
+	for _, t := range m.totals {
+		snap.Models = append(snap.Models, *t)
+	}
+	m.mu.Unlock()
+	snap.Paused = snap.DailyBudgetUSD > 0 && snap.SpentTodayUSD >= snap.DailyBudgetUSD
+	sort.Slice(snap.Models, func(i, j int) bool { return snap.Models[i].Model < snap.Models[j].Model })
+	return snap
+}
+
+// meteredExtractor records every provider attempt, including failed ones
+type meteredExtractor struct {
+	inner Extractor
+	meter *Meter
+}
+
+// WithMetering wraps a provider; New applies it inside WithResilience so each retry is counted
+func WithMetering(inner Extractor, meter *Meter) Extractor {
+	return &meteredExtractor{inner: inner, meter: meter}
+}
+
+func (e *meteredExtractor) Model() string { return e.inner.Model() }
+
+func (e *meteredExtractor) Prompt() Prompt { return e.inner.Prompt() }
+
+func (e *meteredExtractor) Extract(ctx context.Context, restaurant, review string, hintDish string) (*ExtractResponse, error) {
+	start := time.Now()
+	resp, err := e.inner.Extract(ctx, restaurant, review, hintDish)
+	call := CallRecord{Model: e.inner.Model(), Outcome: OutcomeOK, Latency: time.Since(start), At: time.Now()}
+	switch {
+	case err != nil:
+		call.Outcome = string(KindOf(err))
+	case resp == nil || resp.Model == "":
+		// No provider call was made (e.g. no API key)
+		return resp, err
+	default:
+		call.PromptTokens = resp.Usage.PromptTokens
+		call.CompletionTokens = resp.Usage.CompletionTokens
+		call.ResponseFormat = resp.Format
+		call.UsedFallback = resp.UsedFallback
+	}
+	e.meter.record(ctx, call)
+	return resp, err
+}
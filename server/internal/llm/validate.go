@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Allowed enum values, as listed in the extraction prompt
+var (
+	allowedCuisines = []string{"thai", "chinese", "japanese", "korean", "italian", "american", "vietnamese", "indian", "mexican", "fusion", "others"}
+	allowedRestrict = []string{"halal", "vegan", "buddhist vegan"}
+)
+
+// Limits for model-produced strings; longer values are almost always sentences, not tokens
+const (
+	maxDishRunes      = 80
+	maxTokenRunes     = 30
+	maxTokensPerList  = 10
+	defaultCuisineTag = "others"
+)
+
+// ValidationWarning describes one repair or rejection made to a model answer
+type ValidationWarning struct {
+	Item    int    `json:"item"` // index in the model's answer
+	Dish    string `json:"dish,omitempty"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Round   int    `json:"round,omitempty"` // 1 = first answer, 2 = re-asked answer; 0 when not re-asked
+}
+
+// ValidateItems checks items against the prompt's schema. Bad items are dropped, fixable fields
+// are repaired, and every change is reported. The input slice is not modified.
+func ValidateItems(items []ExtractItem, restaurant string) ([]ExtractItem, []ValidationWarning) {
+	var out []ExtractItem
+	var warnings []ValidationWarning
+	for i, it := range items {
+		warn := func(field, code, format string, args ...any) {
+			warnings = append(warnings, ValidationWarning{Item: i, Dish: it.Dish, Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+		}
+
+		it.Dish = strings.TrimSpace(it.Dish)
+		if it.Dish == "" {
+			warn("dish", "dish_empty", "item has no dish name and was dropped")
+			continue
+		}
+		if CurrentLexicon().IsForbiddenDish(it.Dish) {
+			warn("dish", "dish_placeholder", "%q is a placeholder, not a dish; item dropped", it.Dish)
+			continue
+		}
+		if utf8.RuneCountInString(it.Dish) > maxDishRunes {
+			warn("dish", "dish_too_long", "dish name longer than %d characters; item dropped", maxDishRunes)
+			continue
+		}
+
+		if restaurant != "" && it.Restaurant != restaurant {
+			if strings.TrimSpace(it.Restaurant) != "" {
+				warn("restaurant", "restaurant_mismatch", "restaurant %q replaced with %q", it.Restaurant, restaurant)
+			}
+			it.Restaurant = restaurant
+		}
+
+		if it.Cuisine != nil {
+			v := strings.ToLower(strings.TrimSpace(*it.Cuisine))
+			switch {
+			case v == "" || v == "null":
+				it.Cuisine = nil
+			case contains(allowedCuisines, v):
+				it.Cuisine = &v
+			default:
+				warn("cuisine", "cuisine_invalid", "cuisine %q is not allowed; set to %q", *it.Cuisine, defaultCuisineTag)
+				other := defaultCuisineTag
+				it.Cuisine = &other
+			}
+		}
+		if it.Restriction != nil {
+			v := strings.ToLower(strings.TrimSpace(*it.Restriction))
+			switch {
+			case v == "" || v == "null" || v == "none":
+				it.Restriction = nil
+			case contains(allowedRestrict, v):
+				it.Restriction = &v
+			default:
+				warn("restriction", "restriction_invalid", "restriction %q is not allowed; removed", *it.Restriction)
+				it.Restriction = nil
+			}
+		}
+
+		it.Sentiment.Positive = cleanTokens(it.Sentiment.Positive, "sentiment.positive", warn)
+		it.Sentiment.Negative = cleanTokens(it.Sentiment.Negative, "sentiment.negative", warn)
+		out = append(out, it)
+	}
+	return out, warnings
+}
+
+// cleanTokens trims, dedupes and bounds one sentiment list
+func cleanTokens(tokens []string, field string, warn func(field, code, format string, args ...any)) []string {
+	out := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		t = strings.Trim(strings.TrimSpace(t), ".,!?:;\"'`")
+		switch {
+		case t == "":
+			continue
+		case strings.ContainsAny(t, "\n{}[]<>"):
+			warn(field, "token_invalid", "token %q contains markup; dropped", t)
+			continue
+		case utf8.RuneCountInString(t) > maxTokenRunes:
+			warn(field, "token_too_long", "token %q is longer than %d characters; dropped", t, maxTokenRunes)
+			continue
+		case contains(out, t):
+			continue
+		}
+		if len(out) == maxTokensPerList {
+			warn(field, "too_many_tokens", "more than %d tokens; extra tokens dropped", maxTokensPerList)
+			break
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// reaskPrompt asks the model to fix the problems found in its previous answer
+func reaskPrompt(warnings []ValidationWarning) string {
+	var b strings.Builder
+	b.WriteString("Your previous answer did not follow the schema:\n")
+	for _, w := range warnings {
+		fmt.Fprintf(&b, "- item %d: %s\n", w.Item, w.Message)
+	}
+	fmt.Fprintf(&b, "cuisine must be one of [%s]; restriction one of [%s] or null. ", strings.Join(allowedCuisines, ","), strings.Join(allowedRestrict, ","))
+	fmt.Fprintf(&b, "Sentiment tokens are short words (at most %d characters). ", maxTokenRunes)
+	b.WriteString("Return the corrected JSON object only.")
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bestchayapol/DishDive/internal/auth"
+	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/gofiber/fiber/v2"
+)
+
+// fiber.Ctx locals keys set by RequireAuth
+const (
+	LocalUserID    = "userID"    // authenticated user ID (uint)
+	LocalSessionID = "sessionID" // refresh-token family of the access token (string)
+)
+
+// SessionChecker reports whether a login session has not been logged out or revoked
+type SessionChecker interface {
+	IsSessionActive(userID uint, sessionID string) (bool, error)
+}
+
+// Error reasons used in the envelope's "error" field
+const (
+	reasonUnauthorized = "unauthorized"
+	reasonForbidden    = "forbidden"
+)
+
+// Abort writes the standard error envelope and stops the handler chain
+func Abort(c *fiber.Ctx, status int, reason string, message string) error {
+	return c.Status(status).JSON(dtos.ErrorResponse{
+		Status:  status,
+		Error:   reason,
+		Message: message,
+	})
+}
+
+// RequireAuth validates the bearer token, checks that its session is still active and
+// stores the user and session IDs in c.Locals
+func RequireAuth(tokens auth.TokenService, sessions SessionChecker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := strings.TrimSpace(c.Get(fiber.HeaderAuthorization))
+		if header == "" {
+			return Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "token is missing")
+		}
+		if !strings.HasPrefix(header, "Bearer ") {
+			return Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "authorization header must use the Bearer scheme")
+		}
+		token, err := tokens.Verify(header)
+		if errors.Is(err, auth.ErrExpiredToken) {
+			return Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "token has expired")
+		}
+		if err != nil {
+			return Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "invalid token")
+		}
+		// Tokens without a session predate expiry/revocation support and are no longer accepted
+		if token.SessionID == "" {
+			return Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "token has no session, please log in again")
+		}
+		active, err := sessions.IsSessionActive(token.UserID, token.SessionID)
+		if err != nil {
+			return Abort(c, fiber.StatusInternalServerError, "internal_error", "could not verify session")
+		}
+		if !active {
+			return Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "session has been revoked")
+		}
+		c.Locals(LocalUserID, token.UserID)
+		c.Locals(LocalSessionID, token.SessionID)
+		return c.Next()
+	}
+}
+
+// RequireSameUser rejects requests whose path, query or JSON body user_id differs from the token subject.
+// It must run after RequireAuth.
+func RequireSameUser() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authID, ok := UserID(c)
+		if !ok {
+			return Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "authentication required")
+		}
+
+		// Path params use both spellings across the API (:userID and :UserID); query uses ?userID=
+		for _, raw := range []string{c.Params("userID"), c.Params("UserID"), c.Query("userID")} {
+			if raw == "" {
+				continue
+			}
+			id, err := strconv.Atoi(raw)
+			if err != nil {
+				return Abort(c, fiber.StatusBadRequest, "bad_request", "invalid user ID")
+			}
+			if id < 0 || uint(id) != authID {
+				return Abort(c, fiber.StatusForbidden, reasonForbidden, "user ID does not match the authenticated user")
+			}
+		}
+
+		// JSON bodies may carry user_id. This only covers application/json; handlers that read
+		// user_id from a body must still resolve it with ActingUserID.
+		if body := c.Body(); len(body) > 0 && strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+			var probe struct {
+				UserID *int64 `json:"user_id"`
+			}
+			if err := json.Unmarshal(body, &probe); err == nil && probe.UserID != nil {
+				if *probe.UserID < 0 || uint(*probe.UserID) != authID {
+					return Abort(c, fiber.StatusForbidden, reasonForbidden, "user_id does not match the authenticated user")
+				}
+			}
+		}
+		return c.Next()
+	}
+}
+
+// SessionID returns the session ID of the access token stored by RequireAuth
+func SessionID(c *fiber.Ctx) string {
+	id, _ := c.Locals(LocalSessionID).(string)
+	return id
+}
+
+// ActingUserID returns the authenticated user for a handler whose request body may name a
+// user_id. A non-zero bodyUserID other than the token subject is refused with 403, whatever
+// the body's content type. ok is false when the response has already been written.
+func ActingUserID(c *fiber.Ctx, bodyUserID uint) (userID uint, ok bool, err error) {
+	authID, authed := UserID(c)
+	if !authed {
+		return 0, false, Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "authentication required")
+	}
+	if bodyUserID != 0 && bodyUserID != authID {
+		return 0, false, Abort(c, fiber.StatusForbidden, reasonForbidden, "user_id does not match the authenticated user")
+	}
+	return authID, true, nil
+}
+
+// UserID returns the authenticated user ID stored by RequireAuth
+func UserID(c *fiber.Ctx) (uint, bool) {
+	id, ok := c.Locals(LocalUserID).(uint)
+	return id, ok && id > 0
+}
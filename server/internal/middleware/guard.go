@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sensitiveJSONKeys must never appear as keys in a JSON response body
+var sensitiveJSONKeys = [][]byte{
+	[]byte(`"password_hash":`),
+}
+
+// GuardSensitiveFields fails any JSON response that serialises a credential field.
+// It is a last line of defence: a handler that returns an entity or DTO with a
+// password_hash key gets a 500 instead of leaking the hash.
+func GuardSensitiveFields() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		// Streams (e.g. SSE) are never buffered here; reading their body would block
+		if c.Response().IsBodyStream() || !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return nil
+		}
+		body := c.Response().Body()
+		for _, key := range sensitiveJSONKeys {
+			if bytes.Contains(body, key) {
+				log.Printf("[guard] blocked response for %s %s: body contains %s", c.Method(), c.Path(), key)
+				c.Response().ResetBody()
+				return Abort(c, fiber.StatusInternalServerError, "internal_error", "response blocked by sensitive field guard")
+			}
+		}
+		return nil
+	}
+}
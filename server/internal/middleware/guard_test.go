@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const leakedHash = "$2a$10$hash-that-must-not-leak"
+
+func TestGuardSensitiveFieldsBlocksPasswordHash(t *testing.T) {
+	app := fiber.New()
+	app.Use(GuardSensitiveFields())
+	app.Get("/struct", func(c *fiber.Ctx) error {
+		return c.JSON(struct {
+			UserID       uint   `json:"user_id"`
+			PasswordHash string `json:"password_hash"`
+		}{1, leakedHash})
+	})
+	app.Get("/map", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"users": []fiber.Map{{"user_id": 1, "password_hash": leakedHash}}})
+	})
+	app.Get("/safe", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"user_id": 1})
+	})
+
+	for _, tc := range []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/struct", fiber.StatusInternalServerError},
+		{"/map", fiber.StatusInternalServerError},
+		{"/safe", fiber.StatusOK},
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tc.path, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", tc.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tc.wantStatus {
+			t.Errorf("GET %s: status %d, want %d (body %s)", tc.path, resp.StatusCode, tc.wantStatus, body)
+		}
+		if strings.Contains(string(body), "password_hash") || strings.Contains(string(body), leakedHash) {
+			t.Errorf("GET %s: body leaks the password hash: %s", tc.path, body)
+		}
+	}
+}
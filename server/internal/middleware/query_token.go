@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// TokenFromQuery copies ?<param>=<token> into the Authorization header when no header was
+// sent. Use it only on routes whose clients cannot set headers (e.g. EventSource), since
+// query strings end up in access logs.
+func TokenFromQuery(param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) == "" {
+			if token := c.Query(param); token != "" {
+				c.Request().Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+			}
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bestchayapol/DishDive/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxUsernameKey matches the users.user_name column; longer names cannot exist and get no bucket
+const maxUsernameKey = 100
+
+// RateLimit applies limit per route and client: authenticated requests are keyed by user ID,
+// anonymous ones by client IP. Store failures are logged and let the request through so a
+// database hiccup does not take the endpoint down.
+func RateLimit(store ratelimit.Store, route string, limit ratelimit.Limit) fiber.Handler {
+	return rateLimit(store, limit, func(c *fiber.Ctx) string {
+		if userID, ok := UserID(c); ok {
+			return fmt.Sprintf("%s:user:%d", route, userID)
+		}
+		return route + ":ip:" + c.IP()
+	})
+}
+
+// RateLimitByUsername applies limit per route and the user_name in the JSON body, whatever IP
+// the request comes from, so guessing one account's password from many addresses is limited
+// too. Requests without a user_name pass through; the handler rejects them.
+func RateLimitByUsername(store ratelimit.Store, route string, limit ratelimit.Limit) fiber.Handler {
+	return rateLimit(store, limit, func(c *fiber.Ctx) string {
+		var body struct {
+			Username string `json:"user_name"`
+		}
+		if err := json.Unmarshal(c.Body(), &body); err != nil {
+			return ""
+		}
+		name := strings.TrimSpace(body.Username)
+		if name == "" || len(name) > maxUsernameKey {
+			return ""
+		}
+		return route + ":username:" + name
+	})
+}
+
+// rateLimit applies limit to the bucket keyOf names; an empty key skips the limit
+func rateLimit(store ratelimit.Store, limit ratelimit.Limit, keyOf func(c *fiber.Ctx) string) fiber.Handler {
+	if !limit.Enabled() {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	return func(c *fiber.Ctx) error {
+		key := keyOf(c)
+		if key == "" {
+			return c.Next()
+		}
+		res, err := store.Allow(c.UserContext(), key, limit)
+		if err != nil {
+			log.Printf("[ratelimit] store error for %s, allowing request: %v", key, err)
+			return c.Next()
+		}
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		if !res.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ratelimit.RetryAfterSeconds(res.RetryAfter)))
+			return Abort(c, fiber.StatusTooManyRequests, "rate_limited", "too many requests, retry later")
+		}
+		return c.Next()
+	}
+}
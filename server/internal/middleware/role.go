@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocalRole is the fiber.Ctx locals key set by RequireRole
+const LocalRole = "role"
+
+// RoleLookup resolves the current role of a user; roles are read per request so
+// promotions and demotions apply without waiting for tokens to expire
+type RoleLookup interface {
+	GetUserRole(userID uint) (string, error)
+}
+
+// RequireRole rejects authenticated users whose role is not one of allowed.
+// It must run after RequireAuth.
+func RequireRole(roles RoleLookup, allowed ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := UserID(c)
+		if !ok {
+			return Abort(c, fiber.StatusUnauthorized, reasonUnauthorized, "authentication required")
+		}
+		role, err := roles.GetUserRole(userID)
+		if err != nil {
+			return Abort(c, fiber.StatusInternalServerError, "internal_error", "could not resolve user role")
+		}
+		for _, r := range allowed {
+			if role == r {
+				c.Locals(LocalRole, role)
+				return c.Next()
+			}
+		}
+		return Abort(c, fiber.StatusForbidden, reasonForbidden, "insufficient permissions")
+	}
+}
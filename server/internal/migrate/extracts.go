@@ -0,0 +1,26 @@
+package migrate
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// MarkCurrentExtracts makes the newest review_extracts row current for every source that has
+// none, which covers rows written before extraction history was kept. Safe to run on every start.
+func MarkCurrentExtracts(db *gorm.DB) error {
+	res := db.Exec(`
+		UPDATE review_extracts re SET is_current = TRUE
+		WHERE re.rev_ext_id IN (
+			SELECT MAX(rev_ext_id) FROM review_extracts
+			GROUP BY source_id, source_type
+			HAVING NOT BOOL_OR(is_current)
+		)`)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("[migrate] marked %d review extract(s) as current", res.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/normalize"
+	"github.com/bestchayapol/DishDive/internal/repository"
+	"gorm.io/gorm"
+)
+
+// RepairStrippedKeywords restores keywords that older normalizers stored with their Thai vowel and
+// tone marks removed (e.g. "เคม" for "เค็ม"). The correct spelling is looked up by FuzzyKey among
+// known keywords, aliases and the tokens of current review extracts; names with more than one
+// candidate are left alone. A repaired keyword that now duplicates an existing one is merged into
+// it. Scans every keyword, so main.go runs it once through RunOnce.
+func RepairStrippedKeywords(db *gorm.DB) error {
+	var keywords []entities.Keyword
+	if err := db.Find(&keywords).Error; err != nil {
+		return err
+	}
+	var suspects []entities.Keyword
+	for _, kw := range keywords {
+		if looksStripped(kw.Keyword) {
+			suspects = append(suspects, kw)
+		}
+	}
+	if len(suspects) == 0 {
+		return nil
+	}
+
+	spellings, verbatim, err := correctSpellings(db)
+	if err != nil {
+		return err
+	}
+
+	repaired, merged := 0, 0
+	for _, kw := range suspects {
+		// Words without marks exist (แพง); only rewrite names no source spells that way
+		candidates := spellings[normalize.FuzzyKey(kw.Keyword)]
+		if verbatim[kw.Keyword] || len(candidates) == 0 {
+			continue
+		}
+		if len(candidates) > 1 {
+			log.Printf("[migrate] keyword %d %q has several possible spellings; left as is", kw.KeywordID, kw.Keyword)
+			continue
+		}
+		var fixed string
+		for w := range candidates {
+			fixed = w
+		}
+		category, sentiment := kw.Category, kw.Sentiment
+		if category == "" || category == "others" {
+			category, sentiment = normalize.Categorize(fixed, kw.Sentiment)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var target entities.Keyword
+			err := tx.Where("keyword = ? AND category = ? AND sentiment = ? AND keyword_id <> ?", fixed, category, sentiment, kw.KeywordID).
+				First(&target).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				repaired++
+				return tx.Model(&entities.Keyword{}).Where("keyword_id = ?", kw.KeywordID).
+					Updates(map[string]any{"keyword": fixed, "category": category, "sentiment": sentiment}).Error
+			}
+			if err != nil {
+				return err
+			}
+			merged++
+			return repository.MergeKeyword(tx, kw.KeywordID, target.KeywordID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if repaired+merged > 0 {
+		log.Printf("[migrate] repaired %d stripped keyword(s), merged %d into existing keywords", repaired, merged)
+	}
+	return nil
+}
+
+// looksStripped reports Thai text without any vowel or tone mark, the shape the old stripping produced
+func looksStripped(s string) bool {
+	hasThai := false
+	for _, r := range s {
+		if r >= 0x0E01 && r <= 0x0E5B {
+			hasThai = true
+		}
+	}
+	return hasThai && normalize.FuzzyKey(s) == strings.ToLower(normalize.CleanText(s))
+}
+
+// correctSpellings maps FuzzyKey -> set of cleaned spellings that keep their marks. verbatim holds
+// every spelling the sources use, with or without marks. Stored keyword names are not a source,
+// since they are what is being repaired.
+func correctSpellings(db *gorm.DB) (spellings map[string]map[string]struct{}, verbatim map[string]bool, err error) {
+	spellings = map[string]map[string]struct{}{}
+	verbatim = map[string]bool{}
+	add := func(w string) {
+		w = normalize.CleanText(w)
+		if w == "" {
+			return
+		}
+		verbatim[w] = true
+		if looksStripped(w) {
+			return
+		}
+		key := normalize.FuzzyKey(w)
+		if spellings[key] == nil {
+			spellings[key] = map[string]struct{}{}
+		}
+		spellings[key][w] = struct{}{}
+	}
+	for _, w := range normalize.KnownKeywords() {
+		add(w)
+	}
+	var aliases []string
+	if err := db.Model(&entities.KeywordAlias{}).Pluck("alt_word", &aliases).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, a := range aliases {
+		add(a)
+	}
+
+	var extracts []string
+	if err := db.Model(&entities.ReviewExtract{}).Where("is_current").Pluck("data_extract", &extracts).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, raw := range extracts {
+		var items []normalize.ExtractItem
+		if json.Unmarshal([]byte(raw), &items) != nil {
+			continue
+		}
+		for _, it := range items {
+			for _, tok := range append(it.Sentiment.Positive, it.Sentiment.Negative...) {
+				add(tok)
+				add(normalize.BaseKeyword(tok))
+			}
+		}
+	}
+	return spellings, verbatim, nil
+}
@@ -0,0 +1,32 @@
+package migrate
+
+import (
+	"log"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"gorm.io/gorm"
+)
+
+// RunOnce runs a one-off data repair unless data_migrations records it as done, and records
+// it in the same transaction. Runs after AutoMigrate, which creates data_migrations.
+func RunOnce(db *gorm.DB, name string, repair func(db *gorm.DB) error) error {
+	var done int64
+	if err := db.Model(&entities.DataMigration{}).Where("name = ?", name).Count(&done).Error; err != nil {
+		return err
+	}
+	if done > 0 {
+		return nil
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := repair(tx); err != nil {
+			return err
+		}
+		return tx.Create(&entities.DataMigration{Name: name, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("[migrate] %s applied", name)
+	return nil
+}
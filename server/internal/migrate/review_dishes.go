@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// AddReviewDishSourceType adds review_dishes.source_type, which tells user-review rows from
+// web-review rows with the same source_id. Existing rows start as 'web' (the Python pipeline's
+// default). A row becomes 'user' when it matches a user review at the same restaurant and no
+// web review with that id exists there. Rows matching both are left as 'web' and logged; they
+// are then never removed with a user account. Runs only while the column is missing, before
+// AutoMigrate.
+func AddReviewDishSourceType(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable("review_dishes") || m.HasColumn("review_dishes", "source_type") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`ALTER TABLE review_dishes ADD COLUMN source_type VARCHAR(64) NOT NULL DEFAULT 'web'`).Error; err != nil {
+			return err
+		}
+		res := tx.Exec(`
+			UPDATE review_dishes rd SET source_type = 'user'
+			WHERE EXISTS (SELECT 1 FROM user_reviews ur WHERE ur.user_rev_id = rd.source_id AND ur.res_id = rd.res_id)
+			  AND NOT EXISTS (
+				SELECT 1 FROM web_reviews wr JOIN restaurants r ON r.res_name = wr.res_name
+				WHERE wr.web_rev_id = rd.source_id AND r.res_id = rd.res_id)`)
+		if res.Error != nil {
+			return res.Error
+		}
+		var ambiguous int64
+		if err := tx.Raw(`
+			SELECT COUNT(*) FROM review_dishes rd
+			WHERE rd.source_type = 'web'
+			  AND EXISTS (SELECT 1 FROM user_reviews ur WHERE ur.user_rev_id = rd.source_id AND ur.res_id = rd.res_id)`).
+			Scan(&ambiguous).Error; err != nil {
+			return err
+		}
+		log.Printf("[migrate] review_dishes.source_type added; %d row(s) marked as user reviews", res.RowsAffected)
+		if ambiguous > 0 {
+			log.Printf("[migrate] %d review_dishes row(s) match both a user and a web review; left as 'web'", ambiguous)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"gorm.io/gorm"
+)
+
+// DedupeUsernames renames duplicate users.user_name values so the unique index on
+// entities.User can be created. The oldest account keeps the name; later ones get
+// a "_<user_id>" suffix (see freeUsername). Must run before AutoMigrate.
+func DedupeUsernames(db *gorm.DB) error {
+	if !db.Migrator().HasTable("users") {
+		return nil
+	}
+	rows := []struct {
+		UserID   uint
+		UserName string
+	}{}
+	err := db.Raw(`
+		SELECT user_id, user_name FROM (
+			SELECT user_id, user_name,
+			       ROW_NUMBER() OVER (PARTITION BY user_name ORDER BY user_id) AS rn
+			FROM users
+		) d
+		WHERE d.rn > 1
+		ORDER BY user_id`).Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		renamed, err := freeUsername(db, row.UserName, row.UserID)
+		if err != nil {
+			return err
+		}
+		if err := db.Exec(`UPDATE users SET user_name = ? WHERE user_id = ?`, renamed, row.UserID).Error; err != nil {
+			return err
+		}
+		log.Printf("[migrate] duplicate username %q of user %d renamed to %q", row.UserName, row.UserID, renamed)
+	}
+	return nil
+}
+
+// usernameSize is the length of users.user_name (see entities.User), in characters
+const usernameSize = 100
+
+// freeUsername returns "<name>_<userID>", or "<name>_<userID>_<n>" when that is taken, with
+// name cut short so the result fits users.user_name
+func freeUsername(db *gorm.DB, name string, userID uint) (string, error) {
+	for n := 1; ; n++ {
+		suffix := fmt.Sprintf("_%d", userID)
+		if n > 1 {
+			suffix += fmt.Sprintf("_%d", n)
+		}
+		base := []rune(name)
+		if keep := usernameSize - len([]rune(suffix)); len(base) > keep {
+			base = base[:keep]
+		}
+		candidate := string(base) + suffix
+		var taken int64
+		if err := db.Raw(`SELECT COUNT(*) FROM users WHERE user_name = ?`, candidate).Scan(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			return candidate, nil
+		}
+	}
+}
+
+// BootstrapAdmins grants the admin role to the given usernames (config admin.bootstrapUsers)
+// so a fresh deployment has someone who can reach the /admin routes. Runs after AutoMigrate.
+func BootstrapAdmins(db *gorm.DB, usernames []string) error {
+	if len(usernames) == 0 {
+		return nil
+	}
+	result := db.Model(&entities.User{}).
+		Where("user_name IN ? AND role <> ?", usernames, entities.RoleAdmin).
+		Update("role", entities.RoleAdmin)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("[migrate] granted admin role to %d bootstrap user(s)", result.RowsAffected)
+	}
+	return nil
+}
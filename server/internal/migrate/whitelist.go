@@ -0,0 +1,37 @@
+package migrate
+
+import (
+	"log"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/config"
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"gorm.io/gorm"
+)
+
+// SeedRestaurantWhitelist fills restaurant_whitelist from the generated config.WhitelistedRestaurants
+// list when the table is empty. After the first run the table is managed through /admin.
+func SeedRestaurantWhitelist(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&entities.RestaurantWhitelistEntry{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 || len(config.WhitelistedRestaurants) == 0 {
+		return nil
+	}
+	now := time.Now()
+	seen := make(map[string]bool, len(config.WhitelistedRestaurants))
+	entries := make([]entities.RestaurantWhitelistEntry, 0, len(config.WhitelistedRestaurants))
+	for _, name := range config.WhitelistedRestaurants {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		entries = append(entries, entities.RestaurantWhitelistEntry{ResName: name, CreatedAt: now})
+	}
+	if err := db.CreateInBatches(entries, 200).Error; err != nil {
+		return err
+	}
+	log.Printf("[migrate] seeded restaurant_whitelist with %d names", len(entries))
+	return nil
+}
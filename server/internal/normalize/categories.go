@@ -0,0 +1,247 @@
+package normalize
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/thai"
+)
+
+//go:embed keyword_categories.json
+var embeddedCategories []byte
+
+// ReservedCategories are keyword categories managed outside the categories file: the sentiment
+// keyword, cuisine and restriction tags, uncategorised keywords, and the dish detail catch-all
+var ReservedCategories = []string{"system", "cuisine", "restriction", "others", "general"}
+
+var categoryName = regexp.MustCompile(`^[a-z][a-z_]*$`)
+
+// Category is one aspect of a dish review and the keywords that belong to it
+type Category struct {
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases"`  // older names still found in keywords.category
+	Positive []string `json:"positive"` // keywords that are good in themselves
+	Negative []string `json:"negative"`
+	Neutral  []string `json:"neutral"`  // take the sentiment the review gives them ("เผ็ด")
+	Prefixes []string `json:"prefixes"` // segmented words starting with one of these also belong here
+}
+
+// Categories is a validated categories file. Categories are tried in file order for words
+// that are not an exact term, so list the narrow aspects before broad ones like flavor.
+type Categories struct {
+	List []Category `json:"categories"`
+
+	Source string `json:"-"` // file path, or "embedded"
+
+	terms map[string]term   // lowercased term -> category and sentiment
+	names map[string]string // lowercased name or alias -> name
+}
+
+type term struct {
+	category  string
+	sentiment string // "" for neutral terms
+}
+
+// ParseCategories decodes and validates a categories file
+func ParseCategories(data []byte, source string) (*Categories, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var c Categories
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("keyword categories %s: %w", source, err)
+	}
+	c.Source = source
+	if err := c.prepare(); err != nil {
+		return nil, fmt.Errorf("keyword categories %s: %w", source, err)
+	}
+	return &c, nil
+}
+
+func (c *Categories) prepare() error {
+	if len(c.List) == 0 {
+		return errors.New("no categories")
+	}
+	c.terms = map[string]term{}
+	c.names = map[string]string{}
+	reserved := map[string]bool{}
+	for _, r := range ReservedCategories {
+		reserved[r] = true
+	}
+	for i := range c.List {
+		cat := &c.List[i]
+		for _, n := range append([]string{cat.Name}, cat.Aliases...) {
+			switch {
+			case !categoryName.MatchString(n):
+				return fmt.Errorf("category name %q must be lowercase letters and underscores", n)
+			case reserved[n]:
+				return fmt.Errorf("category name %q is reserved", n)
+			case c.names[n] != "":
+				return fmt.Errorf("category name %q is used twice", n)
+			}
+			c.names[n] = cat.Name
+		}
+		if len(cat.Positive)+len(cat.Negative)+len(cat.Neutral)+len(cat.Prefixes) == 0 {
+			return fmt.Errorf("category %q has no terms or prefixes", cat.Name)
+		}
+		for _, list := range []struct {
+			words     []string
+			sentiment string
+		}{{cat.Positive, "positive"}, {cat.Negative, "negative"}, {cat.Neutral, ""}} {
+			for j, w := range list.words {
+				w = CleanText(w)
+				if w == "" {
+					return fmt.Errorf("category %q has a blank term", cat.Name)
+				}
+				key := strings.ToLower(w)
+				if prev, ok := c.terms[key]; ok {
+					return fmt.Errorf("term %q is listed in both %q and %q", w, prev.category, cat.Name)
+				}
+				c.terms[key] = term{category: cat.Name, sentiment: list.sentiment}
+				list.words[j] = w
+			}
+		}
+		for j, p := range cat.Prefixes {
+			if p = CleanText(p); p == "" {
+				return fmt.Errorf("category %q has a blank prefix", cat.Name)
+			}
+			cat.Prefixes[j] = strings.ToLower(p)
+		}
+	}
+	return nil
+}
+
+// Names lists the category names in file order
+func (c *Categories) Names() []string {
+	names := make([]string, len(c.List))
+	for i, cat := range c.List {
+		names[i] = cat.Name
+	}
+	return names
+}
+
+// Canonical maps a category name or one of its aliases, in any case, to the category name
+func (c *Categories) Canonical(name string) (string, bool) {
+	n, ok := c.names[strings.ToLower(strings.TrimSpace(name))]
+	return n, ok
+}
+
+// Terms lists every positive, negative and neutral term, in correct spelling
+func (c *Categories) Terms() []string {
+	var words []string
+	for _, cat := range c.List {
+		words = append(words, cat.Positive...)
+		words = append(words, cat.Negative...)
+		words = append(words, cat.Neutral...)
+	}
+	return words
+}
+
+// categorize files a cleaned base keyword: an exact term takes its category and sentiment;
+// otherwise the first category with a segmented word that is one of its terms or starts with
+// one of its prefixes, with the default sentiment. Anything else is "others".
+func (c *Categories) categorize(token string, defaultSentiment string) (category string, sentiment string) {
+	t := strings.ToLower(strings.TrimSpace(token))
+	ds := defaultSentimentOrNeutral(defaultSentiment)
+	if t == "" {
+		return "others", ds
+	}
+	if tm, ok := c.terms[t]; ok {
+		if tm.sentiment == "" {
+			return tm.category, ds
+		}
+		return tm.category, tm.sentiment
+	}
+
+	// Compare whole segmented words, so e.g. ถูกใจ ("liked") is not read as ถูก ("cheap")
+	words := thai.Current().Words(t)
+	for _, cat := range c.List {
+		for _, w := range words {
+			if tm, ok := c.terms[w]; ok && tm.category == cat.Name {
+				return cat.Name, ds
+			}
+			for _, p := range cat.Prefixes {
+				if strings.HasPrefix(w, p) {
+					return cat.Name, ds
+				}
+			}
+		}
+	}
+	return "others", ds
+}
+
+// LoadCategories reads the override file at path, or the categories embedded in the binary (the
+// only copy kept in the repository) when path is empty. A configured path that cannot be read is
+// an error.
+func LoadCategories(path string) (*Categories, error) {
+	if path == "" {
+		return ParseCategories(embeddedCategories, "embedded")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("keyword categories: %w", err)
+	}
+	return ParseCategories(data, path)
+}
+
+var activeCategories atomic.Pointer[Categories]
+
+func init() {
+	c, err := ParseCategories(embeddedCategories, "embedded")
+	if err != nil {
+		panic(err)
+	}
+	activeCategories.Store(c)
+}
+
+// CurrentCategories returns the categories in use
+func CurrentCategories() *Categories {
+	return activeCategories.Load()
+}
+
+// UseCategories replaces the categories used for new keywords
+func UseCategories(c *Categories) {
+	activeCategories.Store(c)
+}
+
+// WatchCategories polls path every interval and switches to it when its modification time
+// changes. Invalid files are logged and ignored. Blocks until ctx is done.
+func WatchCategories(ctx context.Context, path string, interval time.Duration) {
+	if path == "" || interval <= 0 {
+		return
+	}
+	var last time.Time
+	if info, err := os.Stat(path); err == nil {
+		last = info.ModTime()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(last) {
+			continue
+		}
+		last = info.ModTime()
+		c, err := LoadCategories(path)
+		if err != nil {
+			log.Printf("[normalize] keyword categories reload rejected, keeping the previous ones: %v", err)
+			continue
+		}
+		UseCategories(c)
+		log.Printf("[normalize] keyword categories reloaded from %s (%d categories)", path, len(c.List))
+	}
+}
@@ -0,0 +1,136 @@
+package normalize
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bestchayapol/DishDive/internal/repository"
+)
+
+// Fuzzy dish matching limits
+const (
+	dishMatchSimilarity  = 0.8 // minimum 1 - edit distance / longer key length
+	dishMatchMinContains = 4   // runes a dish name needs to be found inside a longer mention
+)
+
+// DishKey is the key dish names are compared by: FuzzyKey without spaces, so "ข้าวผัด กุ้ง",
+// "ข้าวผัดกุ้ง" and "ขาวผดกง" all meet. Never store it as a name.
+func DishKey(name string) string {
+	return strings.ReplaceAll(FuzzyKey(name), " ", "")
+}
+
+// dishIndex resolves extracted dish names to the dishes of one restaurant by dish name or alias
+type dishIndex struct {
+	// Each maps a form of a name to its dish_id, or to 0 when several dishes share it
+	exact   map[string]uint // lowercased CleanText
+	keys    map[string]uint // DishKey
+	spelled map[string]uint // spelledKey, for edit distance
+}
+
+func newDishIndex(names []repository.DishNameRow) *dishIndex {
+	idx := &dishIndex{exact: map[string]uint{}, keys: map[string]uint{}, spelled: map[string]uint{}}
+	add := func(m map[string]uint, key string, id uint) {
+		if key == "" {
+			return
+		}
+		if prev, ok := m[key]; ok && prev != id {
+			id = 0
+		}
+		m[key] = id
+	}
+	for _, n := range names {
+		add(idx.exact, strings.ToLower(CleanText(n.Name)), n.DishID)
+		add(idx.keys, DishKey(n.Name), n.DishID)
+		add(idx.spelled, spelledKey(n.Name), n.DishID)
+	}
+	return idx
+}
+
+// spelledKey is CleanText lowercased and without spaces. Edit distance is measured on it
+// rather than on DishKey, whose mark-less keys are too short to tell dishes apart.
+func spelledKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(CleanText(name)), " ", "")
+}
+
+// resolve finds the dish a mention refers to: by exact name, by DishKey, then by the closest
+// spelling within dishMatchSimilarity, then by the longest dish name contained in the mention.
+// A tie between different dishes at any step leaves the mention unresolved, and so does a
+// generic mention contained in the names of several dishes ("ข้าวผัด").
+func (d *dishIndex) resolve(mention string) (uint, bool) {
+	if id, ok := d.exact[strings.ToLower(CleanText(mention))]; ok {
+		return id, id != 0
+	}
+	key := DishKey(mention)
+	if key == "" {
+		return 0, false
+	}
+	if id, ok := d.keys[key]; ok {
+		return id, id != 0
+	}
+	containing := map[uint]bool{}
+	for k, id := range d.keys {
+		if strings.Contains(k, key) {
+			containing[id] = true
+		}
+	}
+	if len(containing) > 1 {
+		return 0, false
+	}
+
+	var best uint
+	bestScore, tied := 0.0, false
+	spelled := spelledKey(mention)
+	for k, id := range d.spelled {
+		score := similarity(spelled, k)
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = id, score, false
+		case score == bestScore && id != best:
+			tied = true
+		}
+	}
+	if bestScore >= dishMatchSimilarity {
+		return best, !tied && best != 0
+	}
+
+	best, bestLen, tied := 0, 0, false
+	for k, id := range d.keys {
+		n := utf8.RuneCountInString(k)
+		if n < dishMatchMinContains || !strings.Contains(key, k) {
+			continue
+		}
+		switch {
+		case n > bestLen:
+			best, bestLen, tied = id, n, false
+		case n == bestLen && id != best:
+			tied = true
+		}
+	}
+	return best, bestLen > 0 && !tied && best != 0
+}
+
+// similarity is 1 - Levenshtein distance / length of the longer string, over runes
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longer := max(len(ra), len(rb))
+	if longer == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longer)
+}
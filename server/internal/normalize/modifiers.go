@@ -0,0 +1,180 @@
+package normalize
+
+import (
+	"strings"
+)
+
+// Intensity levels stored on review_dish_keywords; 1 is an unmodified keyword
+const (
+	intensityMild    = 0.5
+	intensityNormal  = 1.0
+	intensityStrong  = 1.5
+	intensityExtreme = 2.0
+)
+
+// modifier is a negator or intensifier written around a keyword
+type modifier struct {
+	text      string
+	negate    bool
+	partial   bool    // weakens instead of fully negating (ไม่ค่อย, "hardly")
+	excess    bool    // "too": the quality is overdone, which is negative even for a positive base
+	intensity float64 // multiplier; 0 leaves intensity unchanged
+}
+
+// Checked longest first within each list; English entries match whole words only
+var (
+	thaiPrefixes = []modifier{
+		{text: "ไม่ค่อย", negate: true, partial: true},
+		{text: "ไม่", negate: true},
+		{text: "ค่อนข้าง", intensity: intensityMild},
+		{text: "แอบ", intensity: intensityMild},
+		{text: "โคตร", intensity: intensityExtreme},
+	}
+	thaiSuffixes = []modifier{
+		{text: "เกินไป", excess: true, intensity: intensityStrong},
+		{text: "นิดหน่อย", intensity: intensityMild},
+		{text: "มากๆ", intensity: intensityExtreme},
+		{text: "สุดๆ", intensity: intensityExtreme},
+		{text: "จริงๆ", intensity: intensityStrong},
+		{text: "นิดนึง", intensity: intensityMild},
+		{text: "หน่อย", intensity: intensityMild},
+		{text: "มาก", intensity: intensityStrong},
+		{text: "จริง", intensity: intensityStrong},
+		{text: "จัด", intensity: intensityStrong},
+		{text: "ไป", excess: true, intensity: intensityStrong},
+	}
+	englishPrefixes = []modifier{
+		{text: "not at all", negate: true},
+		{text: "a little", intensity: intensityMild},
+		{text: "a bit", intensity: intensityMild},
+		{text: "not", negate: true},
+		{text: "no", negate: true},
+		{text: "never", negate: true},
+		{text: "isn't", negate: true},
+		{text: "wasn't", negate: true},
+		{text: "aren't", negate: true},
+		{text: "weren't", negate: true},
+		{text: "don't", negate: true},
+		{text: "doesn't", negate: true},
+		{text: "didn't", negate: true},
+		{text: "hardly", negate: true, partial: true},
+		{text: "barely", negate: true, partial: true},
+		{text: "too", excess: true, intensity: intensityStrong},
+		{text: "overly", excess: true, intensity: intensityStrong},
+		{text: "very", intensity: intensityStrong},
+		{text: "really", intensity: intensityStrong},
+		{text: "so", intensity: intensityStrong},
+		{text: "super", intensity: intensityExtreme},
+		{text: "extremely", intensity: intensityExtreme},
+		{text: "slightly", intensity: intensityMild},
+		{text: "somewhat", intensity: intensityMild},
+		{text: "kinda", intensity: intensityMild},
+	}
+)
+
+// parsedToken is a sentiment token split into its base keyword and modifiers
+type parsedToken struct {
+	Base      string
+	Negated   bool
+	Excess    bool
+	Intensity float64
+}
+
+// parseModifiers strips negators and intensifiers from both ends of a token:
+// "ไม่เค็ม" -> เค็ม negated, "เค็มมาก" -> เค็ม strong, "หวานไป" -> หวาน excess,
+// "not too salty" -> salty negated. A modifier is only stripped if a base remains.
+func parseModifiers(token string) parsedToken {
+	p := parsedToken{Base: strings.TrimSpace(token), Intensity: intensityNormal}
+	partial := false
+	apply := func(m modifier) {
+		if m.negate {
+			p.Negated = !p.Negated
+			partial = partial || m.partial
+		}
+		p.Excess = p.Excess || m.excess
+		if m.intensity > 0 {
+			p.Intensity *= m.intensity
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, m := range englishPrefixes {
+			n := len(m.text)
+			if len(p.Base) > n+1 && p.Base[n] == ' ' && strings.EqualFold(p.Base[:n], m.text) {
+				p.Base = strings.TrimSpace(p.Base[n+1:])
+				apply(m)
+				changed = true
+				break
+			}
+		}
+		if changed {
+			continue
+		}
+		for _, m := range thaiPrefixes {
+			if rest, ok := strings.CutPrefix(p.Base, m.text); ok && strings.TrimSpace(rest) != "" {
+				p.Base = strings.TrimSpace(rest)
+				apply(m)
+				changed = true
+				break
+			}
+		}
+		if changed {
+			continue
+		}
+		for _, m := range thaiSuffixes {
+			if rest, ok := strings.CutSuffix(p.Base, m.text); ok && strings.TrimSpace(rest) != "" {
+				p.Base = strings.TrimSpace(rest)
+				apply(m)
+				changed = true
+				break
+			}
+		}
+	}
+
+	// "not very good" is a plain negation; "ไม่ค่อยอร่อย" a mild one
+	switch {
+	case p.Negated && partial:
+		p.Intensity = intensityMild
+	case p.Negated:
+		p.Intensity = intensityNormal
+	}
+	if p.Intensity < intensityMild/2 {
+		p.Intensity = intensityMild / 2
+	}
+	if p.Intensity > intensityExtreme {
+		p.Intensity = intensityExtreme
+	}
+	return p
+}
+
+// effectiveSentiment applies the modifiers to the base keyword's own sentiment
+func (p parsedToken) effectiveSentiment(base string) string {
+	s := base
+	if p.Excess && s != "neutral" {
+		s = "negative"
+	}
+	if p.Negated {
+		s = flipSentiment(s)
+	}
+	return s
+}
+
+// baseSentiment infers the base keyword's own sentiment from the list the extractor put the
+// whole token in, for bases the normalizer does not know
+func (p parsedToken) baseSentiment(listed string) string {
+	if p.Negated {
+		return flipSentiment(listed)
+	}
+	return listed
+}
+
+func flipSentiment(s string) string {
+	switch s {
+	case "positive":
+		return "negative"
+	case "negative":
+		return "positive"
+	}
+	return s
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/bestchayapol/DishDive/internal/entities"
 	"github.com/bestchayapol/DishDive/internal/repository"
 )
 
@@ -23,22 +24,59 @@ type ExtractItem struct {
 
 type Service struct {
 	Repo  repository.RecommendRepository
-	alias map[string]string
+	alias *aliasIndex
 }
 
 func NewService(repo repository.RecommendRepository) *Service { return &Service{Repo: repo} }
 
 // Run loads the latest extract JSON for (sourceType, sourceID) and writes normalized rows.
+// Each item is attached to its own dish at restaurant resID, found by dish name or alias (see
+// dishIndex); dishID, the dish the review was submitted for, takes items without a dish name
+// and the only item of a single-item review. Other unresolved items are recorded in
+// dish_candidates and their keywords are left out until an admin resolves them.
+// It replaces what earlier runs attached for the review, so running it again, or after the
+// current extraction changed, counts each keyword once. Dish scores and restaurant rollups
+// are recomputed at the end.
 func (s *Service) Run(ctx context.Context, sourceType string, sourceID uint, dishID uint, resID uint) error {
+	if err := s.RunWithoutRecompute(ctx, sourceType, sourceID, dishID, resID); err != nil {
+		return err
+	}
+	return s.Repo.RecomputeScoresAndRestaurants()
+}
+
+// RunWithoutRecompute is Run without the score recompute, which covers every dish. Callers
+// normalizing several reviews call Repo.RecomputeScoresAndRestaurants once afterwards.
+// The review is detached and normalized again in one transaction.
+func (s *Service) RunWithoutRecompute(ctx context.Context, sourceType string, sourceID uint, dishID uint, resID uint) error {
 	// Use the provided ctx; caller may add timeout if desired
 
 	// Lazy-load alias map once
 	if s.alias == nil {
 		if m, err := s.Repo.FetchKeywordAliases(); err == nil {
-			s.alias = m
+			s.alias = newAliasIndex(m)
+		}
+	}
+	return s.Repo.Transaction(func(repo repository.RecommendRepository) error {
+		tx := &Service{Repo: repo, alias: s.alias}
+		return tx.replace(sourceType, sourceID, dishID, resID)
+	})
+}
+
+// detach takes back every keyword link and review dish earlier runs created for the review
+func (s *Service) detach(sourceType string, sourceID uint) error {
+	links, err := s.Repo.GetReviewKeywordLinks(sourceID, sourceType)
+	if err != nil {
+		return fmt.Errorf("load keyword links: %w", err)
+	}
+	for _, l := range links {
+		if err := s.Repo.DetachReviewDishKeyword(l); err != nil {
+			return fmt.Errorf("detach keyword: %w", err)
 		}
 	}
+	return s.Repo.DeleteReviewDishes(sourceID, sourceType)
+}
 
+func (s *Service) replace(sourceType string, sourceID uint, dishID uint, resID uint) error {
 	raw, err := s.Repo.GetLatestReviewExtract(sourceID, sourceType)
 	if err != nil {
 		return err
@@ -48,74 +86,103 @@ func (s *Service) Run(ctx context.Context, sourceType string, sourceID uint, dis
 		// tolerate non-JSON or empty
 		return nil
 	}
-	// Ensure top-level review_dishes entry exists for this review
-	rd, err := s.Repo.EnsureReviewDish(sourceID, dishID, resID)
+	if err := s.detach(sourceType, sourceID); err != nil {
+		return err
+	}
+	names, err := s.Repo.FetchRestaurantDishNames(resID)
 	if err != nil {
-		return fmt.Errorf("ensure ReviewDish: %w", err)
+		return fmt.Errorf("load dish names: %w", err)
+	}
+	dishes := newDishIndex(names)
+
+	reviewDishes := map[uint]uint{} // dish_id -> review_dish_id
+	ensure := func(id uint) (uint, error) {
+		if rdID, ok := reviewDishes[id]; ok {
+			return rdID, nil
+		}
+		rd, err := s.Repo.EnsureReviewDish(sourceID, sourceType, id, resID)
+		if err != nil {
+			return 0, fmt.Errorf("ensure ReviewDish: %w", err)
+		}
+		reviewDishes[id] = rd.RDID
+		return rd.RDID, nil
+	}
+	// Ensure top-level review_dishes entry exists for this review
+	if _, err := ensure(dishID); err != nil {
+		return err
 	}
-	// Attach keywords and update dish keyword frequencies; then recompute rollups
+
+	// Attach keywords and update dish keyword frequencies
+	attached := map[[2]uint]struct{}{}
 	for _, it := range arr {
-		// Positive tokens
-		for _, tok := range it.Sentiment.Positive {
-			name := strings.TrimSpace(canonicalizeToken(tok, s.alias))
-			if name == "" {
-				continue
-			}
-			cat, senti := categorizeKeyword(name, "positive")
-			if kw, err := s.Repo.FindOrCreateKeyword(name, cat, senti); err == nil && kw != nil {
-				_ = s.Repo.EnsureReviewDishKeyword(rd.RDID, kw.KeywordID)
-				_ = s.Repo.BumpDishKeyword(dishID, kw.KeywordID, 1)
+		target, ok := dishID, true
+		if name := CleanText(it.Dish); name != "" {
+			if target, ok = dishes.resolve(name); !ok && len(arr) == 1 {
+				target, ok = dishID, true
 			}
-		}
-		// Negative tokens
-		for _, tok := range it.Sentiment.Negative {
-			name := strings.TrimSpace(canonicalizeToken(tok, s.alias))
-			if name == "" {
+			if !ok {
+				if err := s.Repo.RecordDishCandidate(&entities.DishCandidate{
+					ResID: resID, NameKey: DishKey(name), SourceID: sourceID, SourceType: sourceType,
+					Name: name, HintDishID: dishID,
+				}); err != nil {
+					return fmt.Errorf("record dish candidate: %w", err)
+				}
 				continue
 			}
-			cat, senti := categorizeKeyword(name, "negative")
-			if kw, err := s.Repo.FindOrCreateKeyword(name, cat, senti); err == nil && kw != nil {
-				_ = s.Repo.EnsureReviewDishKeyword(rd.RDID, kw.KeywordID)
-				_ = s.Repo.BumpDishKeyword(dishID, kw.KeywordID, 1)
+		}
+		rdID, err := ensure(target)
+		if err != nil {
+			return err
+		}
+
+		for _, list := range []struct {
+			tokens    []string
+			sentiment string
+		}{{it.Sentiment.Positive, "positive"}, {it.Sentiment.Negative, "negative"}} {
+			for _, tok := range list.tokens {
+				mod := parseModifiers(CleanText(tok))
+				name := strings.TrimSpace(canonicalizeToken(mod.Base, s.alias))
+				if name == "" {
+					continue
+				}
+				cat, senti := categorizeKeyword(name, mod.baseSentiment(list.sentiment))
+				polarity := 1
+				if mod.effectiveSentiment(senti) != senti {
+					polarity = -1
+				}
+				if kw, err := s.Repo.FindOrCreateKeyword(name, cat, senti); err == nil && kw != nil {
+					if err := s.Repo.AttachReviewDishKeyword(rdID, target, kw.KeywordID, polarity, mod.Intensity); err != nil {
+						return fmt.Errorf("attach keyword: %w", err)
+					}
+					attached[[2]uint{target, kw.KeywordID}] = struct{}{}
+				}
 			}
 		}
 	}
-	return s.Repo.RecomputeScoresAndRestaurants()
+	return s.Repo.MarkReviewNormalized(sourceID, sourceType, len(attached))
 }
 
 // (guessCategory removed — not used)
 
-// Port of Python categorize_keyword for parity
-func categorizeKeyword(token string, defaultSentiment string) (category string, sentiment string) {
-	t := strings.ToLower(strings.TrimSpace(token))
-	if t == "" {
-		return "others", defaultSentimentOrNeutral(defaultSentiment)
-	}
-	costPos := map[string]struct{}{"ถูก": {}, "ไม่แพง": {}, "คุ้ม": {}, "คุ้มค่า": {}, "คุ้มราคา": {}, "ราคาดี": {}, "ราคาถูก": {}, "ราคาคุ้มค่า": {}, "คุ้มจริง": {}, "คุ้มมาก": {}, "ราคาสมเหตุสมผล": {}, "สมราคา": {}}
-	costNeg := map[string]struct{}{"แพง": {}, "ราคาแพง": {}, "ไม่คุ้ม": {}, "ไม่คุ้มค่า": {}, "เกินราคา": {}, "ราคาแรง": {}, "แพงไป": {}, "แพงมาก": {}}
-	flavorPos := map[string]struct{}{"อร่อย": {}, "ดี": {}, "ดีมาก": {}, "เด็ด": {}, "แซ่บ": {}, "กรอบ": {}, "นุ่ม": {}, "หอม": {}, "เข้มข้น": {}, "สด": {}, "หวาน": {}, "กลมกล่อม": {}, "เด้ง": {}, "ฉ่ำ": {}, "ละมุน": {}, "หอมนุ่ม": {}}
-	flavorNeg := map[string]struct{}{"เค็ม": {}, "จืด": {}, "คาว": {}, "เหนียว": {}, "หวานไป": {}, "เผ็ดไป": {}, "ไม่อร่อย": {}, "มันไป": {}, "เลี่ยน": {}, "ไหม้": {}, "ดิบ": {}, "แฉะ": {}}
+// KnownKeywords lists every keyword term of the current categories, in correct spelling
+func KnownKeywords() []string {
+	return CurrentCategories().Terms()
+}
 
-	if _, ok := costPos[t]; ok {
-		return "cost", "positive"
-	}
-	if _, ok := costNeg[t]; ok {
-		return "cost", "negative"
-	}
-	if _, ok := flavorPos[t]; ok {
-		return "flavor", "positive"
-	}
-	if _, ok := flavorNeg[t]; ok {
-		return "flavor", "negative"
-	}
+// Categorize returns the category and base sentiment of a cleaned keyword
+func Categorize(keyword string, defaultSentiment string) (category string, sentiment string) {
+	return categorizeKeyword(keyword, defaultSentiment)
+}
 
-	if strings.Contains(t, "ราคา") || strings.Contains(t, "คุ้ม") || strings.Contains(t, "แพง") || strings.Contains(t, "ถูก") {
-		// cost with default sentiment if valid else neutral
-		ds := defaultSentimentOrNeutral(defaultSentiment)
-		return "cost", ds
-	}
-	ds := defaultSentimentOrNeutral(defaultSentiment)
-	return "others", ds
+// BaseKeyword is the cleaned keyword a sentiment token is stored under, without its modifiers
+func BaseKeyword(token string) string {
+	return CleanText(parseModifiers(CleanText(token)).Base)
+}
+
+// categorizeKeyword files a base keyword under one of the current categories (see
+// Categories.categorize)
+func categorizeKeyword(token string, defaultSentiment string) (category string, sentiment string) {
+	return CurrentCategories().categorize(token, defaultSentiment)
 }
 
 func defaultSentimentOrNeutral(s string) string {
@@ -127,38 +194,15 @@ func defaultSentimentOrNeutral(s string) string {
 	}
 }
 
-// canonicalizeToken performs Thai-friendly normalization and applies alias map
-func canonicalizeToken(raw string, alias map[string]string) string {
-	s := strings.TrimSpace(raw)
+// canonicalizeToken cleans a token (see CleanText) and maps it to its base keyword through the
+// alias index; unknown tokens are returned cleaned, in their original spelling
+func canonicalizeToken(raw string, alias *aliasIndex) string {
+	s := CleanText(raw)
 	if s == "" {
 		return s
 	}
-	// collapse whitespace
-	s = strings.Join(strings.Fields(s), " ")
-	// best-effort diacritic strip for Thai tone marks (simple subset)
-	replacers := []string{
-		"\u0E31", "",
-		"\u0E34", "",
-		"\u0E35", "",
-		"\u0E36", "",
-		"\u0E37", "",
-		"\u0E38", "",
-		"\u0E39", "",
-		"\u0E47", "",
-		"\u0E48", "",
-		"\u0E49", "",
-		"\u0E4A", "",
-		"\u0E4B", "",
-		"\u0E4C", "",
-		"\u0E4D", "",
-		"\u0E4E", "",
-	}
-	r := strings.NewReplacer(replacers...)
-	s = r.Replace(s)
-	low := strings.ToLower(s)
-	if base, ok := alias[low]; ok {
+	if base, ok := alias.lookup(s); ok {
 		return base
 	}
 	return s
 }
-
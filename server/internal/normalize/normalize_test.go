@@ -0,0 +1,193 @@
+package normalize
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/repository"
+)
+
+// memRepo keeps the rows normalization writes in memory. Methods normalization does not call
+// are left to the embedded nil interface and panic if reached.
+type memRepo struct {
+	repository.RecommendRepository
+
+	extract     string // current extraction JSON of the review
+	dishNames   []repository.DishNameRow
+	keywords    map[[3]string]uint           // keyword, category, sentiment -> keyword_id
+	reviewDish  map[uint]entities.ReviewDish // review_dish_id -> row
+	links       map[[2]uint]int              // review_dish_id, keyword_id -> polarity
+	frequencies map[[2]uint]uint             // dish_id, keyword_id -> dish_keywords.frequency
+	nextID      uint
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{
+		dishNames: []repository.DishNameRow{
+			{DishID: 1, Name: "ต้มยำกุ้ง"},
+			{DishID: 2, Name: "ผัดไทย"},
+		},
+		keywords:    map[[3]string]uint{},
+		reviewDish:  map[uint]entities.ReviewDish{},
+		links:       map[[2]uint]int{},
+		frequencies: map[[2]uint]uint{},
+	}
+}
+
+func (m *memRepo) id() uint {
+	m.nextID++
+	return m.nextID
+}
+
+func (m *memRepo) Transaction(fn func(repo repository.RecommendRepository) error) error {
+	return fn(m)
+}
+
+func (m *memRepo) FetchKeywordAliases() (map[string]string, error) { return map[string]string{}, nil }
+
+func (m *memRepo) GetLatestReviewExtract(sourceID uint, sourceType string) (string, error) {
+	return m.extract, nil
+}
+
+func (m *memRepo) FetchRestaurantDishNames(resID uint) ([]repository.DishNameRow, error) {
+	return m.dishNames, nil
+}
+
+func (m *memRepo) EnsureReviewDish(sourceID uint, sourceType string, dishID uint, resID uint) (*entities.ReviewDish, error) {
+	for _, rd := range m.reviewDish {
+		if rd.SourceID == sourceID && rd.SourceType == sourceType && rd.DishID == dishID && rd.ResID == resID {
+			return &rd, nil
+		}
+	}
+	rd := entities.ReviewDish{RDID: m.id(), SourceID: sourceID, SourceType: sourceType, DishID: dishID, ResID: resID}
+	m.reviewDish[rd.RDID] = rd
+	return &rd, nil
+}
+
+func (m *memRepo) RecordDishCandidate(c *entities.DishCandidate) error { return nil }
+
+func (m *memRepo) FindOrCreateKeyword(name string, category string, sentiment string) (*entities.Keyword, error) {
+	key := [3]string{name, category, sentiment}
+	if _, ok := m.keywords[key]; !ok {
+		m.keywords[key] = m.id()
+	}
+	return &entities.Keyword{KeywordID: m.keywords[key], Keyword: name, Category: category, Sentiment: sentiment}, nil
+}
+
+func (m *memRepo) AttachReviewDishKeyword(reviewDishID uint, dishID uint, keywordID uint, polarity int, intensity float64) error {
+	link := [2]uint{reviewDishID, keywordID}
+	if _, ok := m.links[link]; ok {
+		return nil
+	}
+	m.links[link] = polarity
+	if polarity >= 0 {
+		m.frequencies[[2]uint{dishID, keywordID}]++
+	}
+	return nil
+}
+
+func (m *memRepo) GetReviewKeywordLinks(sourceID uint, sourceType string) ([]repository.ReviewKeywordLink, error) {
+	var links []repository.ReviewKeywordLink
+	for k, polarity := range m.links {
+		rd := m.reviewDish[k[0]]
+		if rd.SourceID == sourceID && rd.SourceType == sourceType {
+			links = append(links, repository.ReviewKeywordLink{ReviewDishID: k[0], DishID: rd.DishID, KeywordID: k[1], Polarity: polarity})
+		}
+	}
+	return links, nil
+}
+
+func (m *memRepo) DetachReviewDishKeyword(l repository.ReviewKeywordLink) error {
+	link := [2]uint{l.ReviewDishID, l.KeywordID}
+	if _, ok := m.links[link]; !ok {
+		return nil
+	}
+	delete(m.links, link)
+	if l.Polarity < 0 {
+		return nil
+	}
+	dk := [2]uint{l.DishID, l.KeywordID}
+	if m.frequencies[dk] <= 1 {
+		delete(m.frequencies, dk)
+	} else {
+		m.frequencies[dk]--
+	}
+	return nil
+}
+
+func (m *memRepo) DeleteReviewDishes(sourceID uint, sourceType string) error {
+	for id, rd := range m.reviewDish {
+		if rd.SourceID == sourceID && rd.SourceType == sourceType {
+			delete(m.reviewDish, id)
+		}
+	}
+	return nil
+}
+
+func (m *memRepo) MarkReviewNormalized(sourceID uint, sourceType string, keywordsAttached int) error {
+	return nil
+}
+
+func (m *memRepo) RecomputeScoresAndRestaurants() error { return nil }
+
+// dishLinks lists the review's links by dish rather than review_dish_id, which changes per run
+func (m *memRepo) dishLinks() map[[2]uint]int {
+	out := map[[2]uint]int{}
+	for k, polarity := range m.links {
+		out[[2]uint{m.reviewDish[k[0]].DishID, k[1]}] = polarity
+	}
+	return out
+}
+
+func TestRunReplacesKeywordsOfEarlierExtraction(t *testing.T) {
+	const (
+		extractA = `[{"dish": "ต้มยำกุ้ง", "sentiment": {"positive": ["อร่อย"], "negative": []}},
+		             {"dish": "ผัดไทย", "sentiment": {"positive": [], "negative": ["เค็ม", "ไม่หวาน"]}}]`
+		extractB = `[{"dish": "ต้มยำกุ้ง", "sentiment": {"positive": ["เผ็ด"], "negative": []}}]`
+	)
+	repo := newMemRepo()
+	svc := NewService(repo)
+	run := func(extract string) {
+		t.Helper()
+		repo.extract = extract
+		if err := svc.Run(context.Background(), "user", 7, 1, 1); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+
+	run(extractA)
+	wantFreq, wantLinks := clone(repo.frequencies), clone(repo.dishLinks())
+	if len(wantLinks) == 0 {
+		t.Fatal("extraction A attached no keywords")
+	}
+
+	run(extractB)
+	for dk := range repo.frequencies {
+		if _, ok := wantFreq[dk]; ok && dk[0] == 2 {
+			t.Errorf("keyword %d of extraction A is still counted on dish 2 after switching to B", dk[1])
+		}
+	}
+
+	run(extractA)
+	if !reflect.DeepEqual(repo.frequencies, wantFreq) {
+		t.Errorf("frequencies after A -> B -> A = %v, want %v", repo.frequencies, wantFreq)
+	}
+	if got := repo.dishLinks(); !reflect.DeepEqual(got, wantLinks) {
+		t.Errorf("links after A -> B -> A = %v, want %v", got, wantLinks)
+	}
+
+	run(extractA)
+	if !reflect.DeepEqual(repo.frequencies, wantFreq) {
+		t.Errorf("frequencies after running A again = %v, want %v", repo.frequencies, wantFreq)
+	}
+}
+
+func clone[K comparable, V any](m map[K]V) map[K]V {
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
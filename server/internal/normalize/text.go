@@ -0,0 +1,94 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// invisible are zero-width and formatting characters that LLM output and copy-paste leave in tokens
+var invisible = map[rune]bool{
+	'\u00AD': true, // soft hyphen
+	'\u200B': true, // zero width space
+	'\u200C': true, // zero width non-joiner
+	'\u200D': true, // zero width joiner
+	'\u2060': true, // word joiner
+	'\uFEFF': true, // byte order mark
+}
+
+// Thai spellings NFC leaves alone: sara am (U+0E33) typed as nikhahit (U+0E4D) + sara aa (U+0E32),
+// optionally with a tone mark in between (นํ้า -> น้ำ)
+var saraAmFixer = strings.NewReplacer(
+	"\u0E4D\u0E48\u0E32", "\u0E48\u0E33",
+	"\u0E4D\u0E49\u0E32", "\u0E49\u0E33",
+	"\u0E4D\u0E4A\u0E32", "\u0E4A\u0E33",
+	"\u0E4D\u0E4B\u0E32", "\u0E4B\u0E33",
+	"\u0E4D\u0E32", "\u0E33",
+)
+
+// CleanText is the form keywords are stored and displayed in: NFC, without zero-width characters,
+// with sara am composed and whitespace collapsed. Thai vowels and tone marks are kept.
+func CleanText(s string) string {
+	s = norm.NFC.String(s)
+	s = strings.Map(func(r rune) rune {
+		if invisible[r] {
+			return -1
+		}
+		return r
+	}, s)
+	s = saraAmFixer.Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// FuzzyKey is a lossy key for alias lookup only: CleanText, lowercased and without Thai vowel
+// and tone marks, so misspelled marks still find their alias. Never store or display it.
+func FuzzyKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isThaiMark(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, CleanText(s))
+}
+
+// isThaiMark reports the Thai vowel and tone marks written above or below a consonant
+// (U+0E31, U+0E34–U+0E3A, U+0E47–U+0E4E)
+func isThaiMark(r rune) bool {
+	return r == 0x0E31 || (r >= 0x0E34 && r <= 0x0E3A) || (r >= 0x0E47 && r <= 0x0E4E)
+}
+
+// aliasIndex resolves alias words to their base keyword, exactly first and then by FuzzyKey.
+// Fuzzy keys shared by aliases of different keywords are dropped rather than guessed.
+type aliasIndex struct {
+	exact map[string]string
+	fuzzy map[string]string
+}
+
+func newAliasIndex(aliases map[string]string) *aliasIndex {
+	idx := &aliasIndex{exact: map[string]string{}, fuzzy: map[string]string{}}
+	ambiguous := map[string]bool{}
+	for alt, base := range aliases {
+		idx.exact[strings.ToLower(CleanText(alt))] = base
+		key := FuzzyKey(alt)
+		if prev, ok := idx.fuzzy[key]; ok && prev != base {
+			ambiguous[key] = true
+		}
+		idx.fuzzy[key] = base
+	}
+	for key := range ambiguous {
+		delete(idx.fuzzy, key)
+	}
+	return idx
+}
+
+func (a *aliasIndex) lookup(s string) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	if base, ok := a.exact[strings.ToLower(s)]; ok {
+		return base, true
+	}
+	base, ok := a.fuzzy[FuzzyKey(s)]
+	return base, ok
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileNotifier appends one JSON line per notification to a local file (outbox)
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	if path == "" {
+		path = filepath.Join(".data", "notifications.jsonl")
+	}
+	return &FileNotifier{path: path}
+}
+
+type fileRecord struct {
+	Kind      string    `json:"kind"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"user_name"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+func (n *FileNotifier) SendPasswordReset(ctx context.Context, to Recipient, token string, expiresAt time.Time) error {
+	line, err := json.Marshal(fileRecord{
+		Kind:      "password_reset",
+		UserID:    to.UserID,
+		Username:  to.Username,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		SentAt:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(n.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
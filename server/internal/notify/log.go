@@ -0,0 +1,17 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// LogNotifier prints notifications to the server log; meant for local development only
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier { return &LogNotifier{} }
+
+func (n *LogNotifier) SendPasswordReset(ctx context.Context, to Recipient, token string, expiresAt time.Time) error {
+	log.Printf("[notify] password reset for user %d (%s): token=%s expires=%s", to.UserID, to.Username, token, expiresAt.Format(time.RFC3339))
+	return nil
+}
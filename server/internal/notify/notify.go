@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Recipient identifies who a notification is for; users have no email yet,
+// so implementations decide how to reach them (log line, file, later SMTP/push)
+type Recipient struct {
+	UserID   uint
+	Username string
+}
+
+// Notifier delivers account notifications such as password reset tokens
+type Notifier interface {
+	SendPasswordReset(ctx context.Context, to Recipient, token string, expiresAt time.Time) error
+}
+
+// New picks an implementation by driver name ("log" or "file"); unknown drivers fall back to log
+func New(driver string, filePath string) Notifier {
+	switch driver {
+	case "file":
+		return NewFileNotifier(filePath)
+	default:
+		return NewLogNotifier()
+	}
+}
@@ -0,0 +1,74 @@
+// Package ratelimit provides token-bucket rate limiting over a pluggable Store.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limit allows Burst requests at once, refilled at Burst per Per
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// Enabled reports whether the limit should be enforced
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Per > 0
+}
+
+// Result is the outcome of one Allow call
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // time until the next token when !Allowed
+}
+
+// Store keeps bucket state per key. Implementations must be safe for concurrent use.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Take applies one request to a bucket holding tokens as of last and returns the new token
+// count. It is shared by all stores so they behave identically.
+func Take(tokens float64, last time.Time, now time.Time, limit Limit) (float64, Result) {
+	capacity := float64(limit.Burst)
+	perToken := limit.Per / time.Duration(limit.Burst)
+	if elapsed := now.Sub(last); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+float64(elapsed)/float64(perToken))
+	}
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+	wait := time.Duration((1 - tokens) * float64(perToken))
+	return tokens, Result{Allowed: false, RetryAfter: wait}
+}
+
+// Error is returned when a request or login attempt is refused for a while;
+// handler.ErrorHandler renders it as 429 with a Retry-After header
+type Error struct {
+	Reason     string // envelope "error" value, e.g. "rate_limited" or "account_locked"
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", e.Message, e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterSeconds rounds up so clients never retry too early
+func (e *Error) RetryAfterSeconds() int {
+	return RetryAfterSeconds(e.RetryAfter)
+}
+
+// RetryAfterSeconds converts a wait to the whole seconds used in the Retry-After header
+func RetryAfterSeconds(d time.Duration) int {
+	secs := int(math.Ceil(d.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
@@ -0,0 +1,48 @@
+package repository
+
+import "github.com/bestchayapol/DishDive/internal/entities"
+
+// AdminRepository backs the /admin routes. Every mutation takes the audit entry to record
+// and writes it in the same transaction, filling in TargetID for newly created rows.
+type AdminRepository interface {
+	// Restaurants (unfiltered by the whitelist)
+	GetRestaurants() ([]entities.Restaurant, error)
+	GetRestaurantByID(resID uint) (*entities.Restaurant, error)
+	SaveRestaurant(restaurant *entities.Restaurant, audit *entities.AdminAuditLog) error
+
+	// Locations
+	GetLocationByID(rlID uint) (*entities.RestaurantLocation, error)
+	SaveLocation(location *entities.RestaurantLocation, audit *entities.AdminAuditLog) error
+	DeleteLocation(rlID uint, audit *entities.AdminAuditLog) error
+
+	// Keywords and aliases
+	GetKeywords(category string) ([]entities.Keyword, error)
+	GetKeywordByID(keywordID uint) (*entities.Keyword, error)
+	SaveKeyword(keyword *entities.Keyword, audit *entities.AdminAuditLog) error
+	RecategorizeKeywords(categories map[uint]string, audit *entities.AdminAuditLog) (merged map[uint]uint, err error)
+	GetDishAliases(dishID uint) ([]entities.DishAlias, error)
+	CreateDishAlias(alias *entities.DishAlias, audit *entities.AdminAuditLog) error
+	DeleteDishAlias(daID uint, audit *entities.AdminAuditLog) error
+	GetKeywordAliases(keywordID uint) ([]entities.KeywordAlias, error)
+	CreateKeywordAlias(alias *entities.KeywordAlias, audit *entities.AdminAuditLog) error
+	DeleteKeywordAlias(kaID uint, audit *entities.AdminAuditLog) error
+
+	// Dish candidates: extracted dish names no dish or alias matched, grouped by name
+	GetDishCandidates(status string, resID uint) ([]DishCandidateGroup, error)
+	ResolveDishCandidates(resID uint, nameKey string, res DishCandidateResolution, audit *entities.AdminAuditLog) ([]entities.DishCandidate, error)
+
+	// Review extractions
+	// SetCurrentReviewExtract switches the current run and calls then in the same transaction
+	SetCurrentReviewExtract(sourceID uint, sourceType string, extractID uint, then func(repo RecommendRepository) error, audit *entities.AdminAuditLog) error
+
+	// Restaurant whitelist
+	GetWhitelist() ([]entities.RestaurantWhitelistEntry, error)
+	AddToWhitelist(entry *entities.RestaurantWhitelistEntry, audit *entities.AdminAuditLog) error
+	RemoveFromWhitelist(wlID uint, audit *entities.AdminAuditLog) error
+
+	// Users
+	SetUserRole(userID uint, role string, audit *entities.AdminAuditLog) error
+
+	// Audit log, newest first
+	GetAuditLogs(limit int, offset int) ([]entities.AdminAuditLog, error)
+}
@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type adminRepositoryDB struct {
+	db *gorm.DB
+}
+
+func NewAdminRepositoryDB(db *gorm.DB) AdminRepository {
+	return &adminRepositoryDB{db: db}
+}
+
+// withAudit runs mutate and inserts the audit row in one transaction. TargetID is read
+// after mutate so creates can report the generated primary key.
+func (r *adminRepositoryDB) withAudit(audit *entities.AdminAuditLog, mutate func(tx *gorm.DB) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := mutate(tx); err != nil {
+			return err
+		}
+		if audit.CreatedAt.IsZero() {
+			audit.CreatedAt = time.Now()
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// deleteByID deletes one row and reports gorm.ErrRecordNotFound when nothing matched
+func deleteByID(tx *gorm.DB, model interface{}, column string, id uint) error {
+	result := tx.Where(column+" = ?", id).Delete(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Restaurant methods
+func (r *adminRepositoryDB) GetRestaurants() ([]entities.Restaurant, error) {
+	var restaurants []entities.Restaurant
+	result := r.db.Order("res_name").Find(&restaurants)
+	return restaurants, result.Error
+}
+
+func (r *adminRepositoryDB) GetRestaurantByID(resID uint) (*entities.Restaurant, error) {
+	var restaurant entities.Restaurant
+	if err := r.db.Where("res_id = ?", resID).First(&restaurant).Error; err != nil {
+		return nil, err
+	}
+	return &restaurant, nil
+}
+
+func (r *adminRepositoryDB) SaveRestaurant(restaurant *entities.Restaurant, audit *entities.AdminAuditLog) error {
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		if err := tx.Save(restaurant).Error; err != nil {
+			return err
+		}
+		audit.TargetID = restaurant.ResID
+		return nil
+	})
+}
+
+// RestaurantLocation methods
+func (r *adminRepositoryDB) GetLocationByID(rlID uint) (*entities.RestaurantLocation, error) {
+	var location entities.RestaurantLocation
+	if err := r.db.Where("rl_id = ?", rlID).First(&location).Error; err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+func (r *adminRepositoryDB) SaveLocation(location *entities.RestaurantLocation, audit *entities.AdminAuditLog) error {
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		if err := tx.Save(location).Error; err != nil {
+			return err
+		}
+		audit.TargetID = location.RLID
+		return nil
+	})
+}
+
+func (r *adminRepositoryDB) DeleteLocation(rlID uint, audit *entities.AdminAuditLog) error {
+	audit.TargetID = rlID
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		return deleteByID(tx, &entities.RestaurantLocation{}, "rl_id", rlID)
+	})
+}
+
+// Keyword methods
+func (r *adminRepositoryDB) GetKeywords(category string) ([]entities.Keyword, error) {
+	var keywords []entities.Keyword
+	q := r.db.Order("category, keyword")
+	if category != "" {
+		q = q.Where("category = ?", category)
+	}
+	result := q.Find(&keywords)
+	return keywords, result.Error
+}
+
+func (r *adminRepositoryDB) GetKeywordByID(keywordID uint) (*entities.Keyword, error) {
+	var keyword entities.Keyword
+	if err := r.db.Where("keyword_id = ?", keywordID).First(&keyword).Error; err != nil {
+		return nil, err
+	}
+	return &keyword, nil
+}
+
+func (r *adminRepositoryDB) SaveKeyword(keyword *entities.Keyword, audit *entities.AdminAuditLog) error {
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		if err := tx.Save(keyword).Error; err != nil {
+			return err
+		}
+		audit.TargetID = keyword.KeywordID
+		return nil
+	})
+}
+
+// RecategorizeKeywords moves keywords to new categories (keyword_id -> category) in one
+// transaction. A keyword that then duplicates another with the same name, category and
+// sentiment is merged into it; merged maps each such keyword_id to the one it went into.
+func (r *adminRepositoryDB) RecategorizeKeywords(categories map[uint]string, audit *entities.AdminAuditLog) (map[uint]uint, error) {
+	merged := map[uint]uint{}
+	err := r.withAudit(audit, func(tx *gorm.DB) error {
+		for id, category := range categories {
+			var kw entities.Keyword
+			if err := tx.Where("keyword_id = ?", id).First(&kw).Error; err != nil {
+				return err
+			}
+			var target entities.Keyword
+			err := tx.Where("keyword = ? AND category = ? AND sentiment = ? AND keyword_id <> ?", kw.Keyword, category, kw.Sentiment, id).
+				First(&target).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Model(&kw).Update("category", category).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := MergeKeyword(tx, id, target.KeywordID); err != nil {
+				return err
+			}
+			merged[id] = target.KeywordID
+		}
+		return nil
+	})
+	return merged, err
+}
+
+// MergeKeyword moves every reference from keyword src to dst and deletes src
+func MergeKeyword(tx *gorm.DB, src, dst uint) error {
+	steps := []string{
+		// Links and preferences that dst already has are dropped instead of duplicated
+		`UPDATE review_dish_keywords SET keyword_id = @dst WHERE keyword_id = @src AND review_dish_id NOT IN
+			(SELECT review_dish_id FROM review_dish_keywords WHERE keyword_id = @dst)`,
+		`DELETE FROM review_dish_keywords WHERE keyword_id = @src`,
+		`UPDATE dish_keywords d SET frequency = d.frequency + s.frequency
+			FROM dish_keywords s WHERE s.keyword_id = @src AND d.keyword_id = @dst AND d.dish_id = s.dish_id`,
+		`DELETE FROM dish_keywords WHERE keyword_id = @src AND dish_id IN
+			(SELECT dish_id FROM dish_keywords WHERE keyword_id = @dst)`,
+		`UPDATE dish_keywords SET keyword_id = @dst WHERE keyword_id = @src`,
+		`UPDATE preference_blacklists SET keyword_id = @dst WHERE keyword_id = @src AND user_id NOT IN
+			(SELECT user_id FROM preference_blacklists WHERE keyword_id = @dst)`,
+		`DELETE FROM preference_blacklists WHERE keyword_id = @src`,
+		`UPDATE keyword_aliases SET keyword_id = @dst WHERE keyword_id = @src`,
+		`UPDATE cuisine_images SET keyword_id = @dst WHERE keyword_id = @src`,
+		`DELETE FROM keywords WHERE keyword_id = @src`,
+	}
+	args := map[string]any{"src": src, "dst": dst}
+	for _, sql := range steps {
+		if err := tx.Exec(sql, args).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Alias methods
+func (r *adminRepositoryDB) GetDishAliases(dishID uint) ([]entities.DishAlias, error) {
+	var aliases []entities.DishAlias
+	result := r.db.Where("dish_id = ?", dishID).Order("alt_name").Find(&aliases)
+	return aliases, result.Error
+}
+
+func (r *adminRepositoryDB) CreateDishAlias(alias *entities.DishAlias, audit *entities.AdminAuditLog) error {
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		if err := tx.Create(alias).Error; err != nil {
+			return err
+		}
+		audit.TargetID = alias.DAID
+		return nil
+	})
+}
+
+func (r *adminRepositoryDB) DeleteDishAlias(daID uint, audit *entities.AdminAuditLog) error {
+	audit.TargetID = daID
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		return deleteByID(tx, &entities.DishAlias{}, "da_id", daID)
+	})
+}
+
+// Dish candidate methods
+
+// DishCandidateGroup is every mention of one unresolved dish name at one restaurant
+type DishCandidateGroup struct {
+	ResID     uint      `json:"res_id"`
+	ResName   string    `json:"res_name"`
+	NameKey   string    `json:"name_key"`
+	Name      string    `json:"name"` // the most common spelling
+	Mentions  int       `json:"mentions"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// DishCandidateResolution says what to do with the mentions of a candidate dish name
+type DishCandidateResolution struct {
+	Status  string              // entities.CandidateResolved or entities.CandidateDismissed
+	DishID  uint                // existing dish the name belongs to
+	NewDish *entities.Dish      // created first when the name is a new dish; its id replaces DishID
+	Alias   *entities.DishAlias // created for DishID when the name is another name of it
+}
+
+func (r *adminRepositoryDB) GetDishCandidates(status string, resID uint) ([]DishCandidateGroup, error) {
+	groups := []DishCandidateGroup{}
+	q := r.db.Table("dish_candidates dc").
+		Select(`dc.res_id, r.res_name, dc.name_key, MODE() WITHIN GROUP (ORDER BY dc.name) AS name,
+			COUNT(*) AS mentions, MIN(dc.created_at) AS first_seen, MAX(dc.created_at) AS last_seen`).
+		Joins("JOIN restaurants r ON r.res_id = dc.res_id").
+		Where("dc.status = ?", status)
+	if resID != 0 {
+		q = q.Where("dc.res_id = ?", resID)
+	}
+	err := q.Group("dc.res_id, r.res_name, dc.name_key").
+		Order("mentions DESC, last_seen DESC").
+		Scan(&groups).Error
+	return groups, err
+}
+
+// ResolveDishCandidates applies res to the pending mentions of nameKey at resID and returns
+// them; gorm.ErrRecordNotFound when there are none
+func (r *adminRepositoryDB) ResolveDishCandidates(resID uint, nameKey string, res DishCandidateResolution, audit *entities.AdminAuditLog) ([]entities.DishCandidate, error) {
+	var mentions []entities.DishCandidate
+	err := r.withAudit(audit, func(tx *gorm.DB) error {
+		if err := tx.Where("res_id = ? AND name_key = ? AND status = ?", resID, nameKey, entities.CandidatePending).
+			Find(&mentions).Error; err != nil {
+			return err
+		}
+		if len(mentions) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		updates := map[string]any{"status": res.Status}
+		if res.Status == entities.CandidateResolved {
+			if res.NewDish != nil {
+				if err := tx.Create(res.NewDish).Error; err != nil {
+					return err
+				}
+				res.DishID = res.NewDish.DishID
+			}
+			if res.Alias != nil {
+				res.Alias.DishID = res.DishID
+				if err := tx.Create(res.Alias).Error; err != nil {
+					return err
+				}
+			}
+			updates["dish_id"] = res.DishID
+			audit.TargetID = res.DishID
+		}
+		return tx.Model(&entities.DishCandidate{}).
+			Where("res_id = ? AND name_key = ? AND status = ?", resID, nameKey, entities.CandidatePending).
+			Updates(updates).Error
+	})
+	return mentions, err
+}
+
+// SetCurrentReviewExtract makes extractID the current extraction of its source, then runs then
+// (normalization) on the same transaction. The other runs are unset before it is set, so the
+// partial unique index on current rows holds throughout. gorm.ErrRecordNotFound when extractID
+// is not a run of the source.
+func (r *adminRepositoryDB) SetCurrentReviewExtract(sourceID uint, sourceType string, extractID uint, then func(repo RecommendRepository) error, audit *entities.AdminAuditLog) error {
+	audit.TargetID = extractID
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		var rec entities.ReviewExtract
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("rev_ext_id = ? AND source_id = ? AND source_type = ?", extractID, sourceID, sourceType).
+			First(&rec).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entities.ReviewExtract{}).
+			Where("source_id = ? AND source_type = ? AND is_current AND rev_ext_id <> ?", sourceID, sourceType, extractID).
+			Update("is_current", false).Error; err != nil {
+			return err
+		}
+		// Normalization details are filled in again when the run is normalized
+		if err := tx.Model(&rec).Updates(map[string]any{"is_current": true, "normalized_at": nil, "keywords_attached": 0}).Error; err != nil {
+			return err
+		}
+		return then(NewRecommendRepositoryDB(tx))
+	})
+}
+
+func (r *adminRepositoryDB) GetKeywordAliases(keywordID uint) ([]entities.KeywordAlias, error) {
+	var aliases []entities.KeywordAlias
+	result := r.db.Where("keyword_id = ?", keywordID).Order("alt_word").Find(&aliases)
+	return aliases, result.Error
+}
+
+func (r *adminRepositoryDB) CreateKeywordAlias(alias *entities.KeywordAlias, audit *entities.AdminAuditLog) error {
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		if err := tx.Create(alias).Error; err != nil {
+			return err
+		}
+		audit.TargetID = alias.KAID
+		return nil
+	})
+}
+
+func (r *adminRepositoryDB) DeleteKeywordAlias(kaID uint, audit *entities.AdminAuditLog) error {
+	audit.TargetID = kaID
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		return deleteByID(tx, &entities.KeywordAlias{}, "ka_id", kaID)
+	})
+}
+
+// Whitelist methods
+func (r *adminRepositoryDB) GetWhitelist() ([]entities.RestaurantWhitelistEntry, error) {
+	var entries []entities.RestaurantWhitelistEntry
+	result := r.db.Order("res_name").Find(&entries)
+	return entries, result.Error
+}
+
+func (r *adminRepositoryDB) AddToWhitelist(entry *entities.RestaurantWhitelistEntry, audit *entities.AdminAuditLog) error {
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = time.Now()
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		audit.TargetID = entry.WLID
+		return nil
+	})
+}
+
+func (r *adminRepositoryDB) RemoveFromWhitelist(wlID uint, audit *entities.AdminAuditLog) error {
+	audit.TargetID = wlID
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		return deleteByID(tx, &entities.RestaurantWhitelistEntry{}, "wl_id", wlID)
+	})
+}
+
+// User methods
+func (r *adminRepositoryDB) SetUserRole(userID uint, role string, audit *entities.AdminAuditLog) error {
+	audit.TargetID = userID
+	return r.withAudit(audit, func(tx *gorm.DB) error {
+		result := tx.Model(&entities.User{}).Where("user_id = ?", userID).Update("role", role)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// Audit log
+func (r *adminRepositoryDB) GetAuditLogs(limit int, offset int) ([]entities.AdminAuditLog, error) {
+	var logs []entities.AdminAuditLog
+	result := r.db.Order("created_at DESC, audit_id DESC").Limit(limit).Offset(offset).Find(&logs)
+	return logs, result.Error
+}
@@ -1,11 +1,13 @@
 package repository
 
 import (
-	"github.com/bestchayapol/DishDive/internal/config"
 	"github.com/bestchayapol/DishDive/internal/entities"
 	"gorm.io/gorm"
 )
 
+// whitelistedResNames selects the restaurant names that may be shown (managed via /admin)
+const whitelistedResNames = "SELECT res_name FROM restaurant_whitelist"
+
 type foodRepositoryDB struct {
 	db *gorm.DB
 }
@@ -41,14 +43,14 @@ func (r *foodRepositoryDB) GetAllRestaurants() ([]entities.Restaurant, error) {
 		Select("DISTINCT restaurants.*").
 		Joins("JOIN restaurant_locations rl ON rl.res_id = restaurants.res_id").
 		Where("rl.latitude IS NOT NULL AND rl.longitude IS NOT NULL AND rl.latitude <> 0 AND rl.longitude <> 0 AND NOT (rl.latitude = ? AND rl.longitude = ?)", 15.870032, 100.992541).
-		Where("restaurants.res_name IN ?", config.WhitelistedRestaurants).
+		Where("restaurants.res_name IN (" + whitelistedResNames + ")").
 		Find(&restaurants)
 	return restaurants, result.Error
 }
 
 func (r *foodRepositoryDB) GetRestaurantByID(resID uint) (*entities.Restaurant, error) {
 	var restaurant entities.Restaurant
-	result := r.db.Where("res_id = ? AND res_name IN ?", resID, config.WhitelistedRestaurants).First(&restaurant)
+	result := r.db.Where("res_id = ? AND res_name IN ("+whitelistedResNames+")", resID).First(&restaurant)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -64,7 +66,7 @@ func (r *foodRepositoryDB) SearchRestaurantsByDish(dishName string, latitude, lo
 		Joins("JOIN restaurant_locations rl ON rl.res_id = restaurants.res_id").
 		Where("dishes.dish_name LIKE ?", "%"+dishName+"%").
 		Where("rl.latitude IS NOT NULL AND rl.longitude IS NOT NULL AND rl.latitude <> 0 and rl.longitude <> 0 AND NOT (rl.latitude = ? AND rl.longitude = ?)", 15.870032, 100.992541).
-		Where("restaurants.res_name IN ?", config.WhitelistedRestaurants).
+		Where("restaurants.res_name IN (" + whitelistedResNames + ")").
 		Find(&restaurants)
 	return restaurants, result.Error
 }
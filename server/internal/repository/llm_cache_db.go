@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/llm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// llmCacheStoreDB keeps extraction results in llm_cache so restarts and replicas share them
+type llmCacheStoreDB struct {
+	db *gorm.DB
+}
+
+func NewLLMCacheStoreDB(db *gorm.DB) llm.CacheStore {
+	return &llmCacheStoreDB{db: db}
+}
+
+func (r *llmCacheStoreDB) GetCachedExtract(ctx context.Context, key string) (*llm.ExtractResponse, error) {
+	var entry entities.LLMCacheEntry
+	err := r.db.WithContext(ctx).Where("cache_key = ?", key).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resp llm.ExtractResponse
+	if err := json.Unmarshal([]byte(entry.Response), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (r *llmCacheStoreDB) PutCachedExtract(ctx context.Context, key string, promptVersion string, resp *llm.ExtractResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	entry := entities.LLMCacheEntry{
+		CacheKey:      key,
+		Model:         resp.Model,
+		PromptVersion: promptVersion,
+		Response:      string(data),
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cache_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"model", "prompt_version", "response"}),
+	}).Create(&entry).Error
+}
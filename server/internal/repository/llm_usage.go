@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/llm"
+)
+
+// LLMUsageDay aggregates the calls of one model on one day
+type LLMUsageDay struct {
+	Day              time.Time
+	Model            string
+	Calls            int64
+	Errors           int64
+	SchemaCalls      int64
+	Fallbacks        int64
+	PromptTokens     int64
+	CompletionTokens int64
+	CostUSD          float64
+	AvgLatencyMS     float64
+}
+
+// LLMUsageRepository stores llm_calls; it also serves as the llm.UsageRecorder
+type LLMUsageRepository interface {
+	llm.UsageRecorder
+	GetLLMUsageDaily(since time.Time) ([]LLMUsageDay, error)
+	GetLLMCallsBySource(sourceID uint, sourceType string) ([]entities.LLMCall, error)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/llm"
+	"gorm.io/gorm"
+)
+
+type llmUsageRepositoryDB struct {
+	db *gorm.DB
+}
+
+func NewLLMUsageRepositoryDB(db *gorm.DB) LLMUsageRepository {
+	return &llmUsageRepositoryDB{db: db}
+}
+
+func (r *llmUsageRepositoryDB) RecordLLMCall(ctx context.Context, call llm.CallRecord) error {
+	row := entities.LLMCall{
+		SourceID:         call.SourceID,
+		SourceType:       call.SourceType,
+		Model:            call.Model,
+		Outcome:          call.Outcome,
+		ResponseFormat:   call.ResponseFormat,
+		UsedFallback:     call.UsedFallback,
+		PromptTokens:     call.PromptTokens,
+		CompletionTokens: call.CompletionTokens,
+		LatencyMS:        call.Latency.Milliseconds(),
+		CostUSD:          call.CostUSD,
+		CreatedAt:        call.At,
+	}
+	return r.db.WithContext(ctx).Create(&row).Error
+}
+
+func (r *llmUsageRepositoryDB) SumLLMCostSince(since time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&entities.LLMCall{}).
+		Select("COALESCE(SUM(cost_usd), 0)").
+		Where("created_at >= ?", since).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *llmUsageRepositoryDB) GetLLMUsageDaily(since time.Time) ([]LLMUsageDay, error) {
+	var rows []LLMUsageDay
+	err := r.db.Model(&entities.LLMCall{}).
+		Select(`date_trunc('day', created_at) AS day, model,
+			COUNT(*) AS calls,
+			COUNT(*) FILTER (WHERE outcome <> ?) AS errors,
+			COUNT(*) FILTER (WHERE response_format = ?) AS schema_calls,
+			COUNT(*) FILTER (WHERE used_fallback) AS fallbacks,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(cost_usd), 0) AS cost_usd,
+			COALESCE(AVG(latency_ms), 0) AS avg_latency_ms`, llm.OutcomeOK, llm.FormatJSONSchema).
+		Where("created_at >= ?", since).
+		Group("day, model").
+		Order("day DESC, model").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *llmUsageRepositoryDB) GetLLMCallsBySource(sourceID uint, sourceType string) ([]entities.LLMCall, error) {
+	var calls []entities.LLMCall
+	err := r.db.Where("source_id = ? AND source_type = ?", sourceID, sourceType).
+		Order("created_at, call_id").
+		Find(&calls).Error
+	return calls, err
+}
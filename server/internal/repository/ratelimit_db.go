@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/ratelimit"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	rateLimitSweepInterval = time.Hour
+	rateLimitIdleRetention = 24 * time.Hour
+)
+
+// rateLimitStoreDB shares bucket state between server replicas through rate_limit_buckets
+type rateLimitStoreDB struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewRateLimitStoreDB(db *gorm.DB) ratelimit.Store {
+	return &rateLimitStoreDB{db: db}
+}
+
+func (r *rateLimitStoreDB) Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	r.sweep()
+
+	var res ratelimit.Result
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// Create a full bucket on first use, then lock the row for the read-modify-write
+		seed := entities.RateLimitBucket{BucketKey: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seed).Error; err != nil {
+			return err
+		}
+		var bucket entities.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket_key = ?", key).First(&bucket).Error; err != nil {
+			return err
+		}
+		var tokens float64
+		tokens, res = ratelimit.Take(bucket.Tokens, bucket.UpdatedAt, now, limit)
+		return tx.Model(&entities.RateLimitBucket{}).Where("bucket_key = ?", key).
+			Updates(map[string]interface{}{"tokens": tokens, "updated_at": now}).Error
+	})
+	return res, err
+}
+
+// sweep deletes long-idle buckets at most once per rateLimitSweepInterval
+func (r *rateLimitStoreDB) sweep() {
+	r.mu.Lock()
+	if time.Since(r.lastSweep) < rateLimitSweepInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastSweep = time.Now()
+	r.mu.Unlock()
+	r.db.Where("updated_at < ?", time.Now().Add(-rateLimitIdleRetention)).Delete(&entities.RateLimitBucket{})
+}
@@ -5,6 +5,9 @@ import (
 )
 
 type RecommendRepository interface {
+	// Transaction runs fn with a repository bound to one database transaction
+	Transaction(fn func(repo RecommendRepository) error) error
+
 	// Unified Settings (New approach)
 	GetUserSettings(userID uint) ([]entities.PreferenceBlacklist, error)
 	GetAllKeywordsWithUserSettings(userID uint) ([]entities.PreferenceBlacklist, error)
@@ -15,17 +18,33 @@ type RecommendRepository interface {
 	// Reviews
 	GetDishReviewPage(dishID uint) (*entities.Dish, *entities.Restaurant, error)
 	SubmitReview(userID uint, dishID uint, resID uint, reviewText string) (uint, error)
+	GetUserReviewByID(reviewID uint) (*entities.UserReview, error)
 	HasReviewExtract(sourceID uint, sourceType string) (bool, error)
-	HasNormalizedReview(sourceID uint) (bool, error)
+	HasNormalizedReview(sourceID uint, sourceType string) (bool, error)
 
 	// Extraction results
-	UpsertReviewExtract(sourceID uint, sourceType string, dataExtract string) error
+	// SaveReviewExtract stores a new extraction run and makes it the current one
+	SaveReviewExtract(rec *entities.ReviewExtract) error
 	GetLatestReviewExtract(sourceID uint, sourceType string) (string, error)
+	GetReviewExtract(sourceID uint, sourceType string) (*entities.ReviewExtract, error)
+	GetReviewExtractHistory(sourceID uint, sourceType string) ([]entities.ReviewExtract, error)
+	MarkReviewNormalized(sourceID uint, sourceType string, keywordsAttached int) error
 
 	// Normalization helpers
-	EnsureReviewDish(sourceID uint, dishID uint, resID uint) (*entities.ReviewDish, error)
+	EnsureReviewDish(sourceID uint, sourceType string, dishID uint, resID uint) (*entities.ReviewDish, error)
+	// FetchRestaurantDishNames lists the dish names and dish aliases of one restaurant
+	FetchRestaurantDishNames(resID uint) ([]DishNameRow, error)
+	// RecordDishCandidate stores an unresolved dish mention; repeating one is a no-op
+	RecordDishCandidate(c *entities.DishCandidate) error
 	FindKeywordByName(name string) (*entities.Keyword, error)
 	EnsureReviewDishKeyword(reviewDishID uint, keywordID uint) error
+	AttachReviewDishKeyword(reviewDishID uint, dishID uint, keywordID uint, polarity int, intensity float64) error
+	// GetReviewKeywordLinks lists the keyword links of every review dish of a source
+	GetReviewKeywordLinks(sourceID uint, sourceType string) ([]ReviewKeywordLink, error)
+	// DetachReviewDishKeyword undoes AttachReviewDishKeyword for one link
+	DetachReviewDishKeyword(link ReviewKeywordLink) error
+	// DeleteReviewDishes removes the review_dishes rows of a source; their links must be detached first
+	DeleteReviewDishes(sourceID uint, sourceType string) error
 	FindOrCreateKeyword(name string, category string, sentiment string) (*entities.Keyword, error)
 	BumpDishKeyword(dishID uint, keywordID uint, delta int) error
 	RecomputeScoresAndRestaurants() error
@@ -35,4 +54,6 @@ type RecommendRepository interface {
 	GetKeywordsByCategory(categories []string) ([]entities.Keyword, error)
 	// Aliases for normalization
 	FetchKeywordAliases() (map[string]string, error)
+	// FetchDictionaryWords lists dish names, keywords and their aliases for word segmentation
+	FetchDictionaryWords() ([]string, error)
 }
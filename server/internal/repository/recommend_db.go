@@ -2,9 +2,11 @@ package repository
 
 import (
 	"strings"
+	"time"
 
 	"github.com/bestchayapol/DishDive/internal/entities"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type recommendRepositoryDB struct {
@@ -20,10 +22,30 @@ type KeywordSettingRow struct {
 	Blacklist  float64 `json:"blacklist"`
 }
 
+// DishNameRow is one name a dish is known by: its dish_name or one of its aliases
+type DishNameRow struct {
+	DishID uint   `json:"dish_id"`
+	Name   string `json:"name"`
+}
+
+// ReviewKeywordLink is one review_dish_keywords row with the dish of its review dish
+type ReviewKeywordLink struct {
+	ReviewDishID uint `json:"review_dish_id"`
+	DishID       uint `json:"dish_id"`
+	KeywordID    uint `json:"keyword_id"`
+	Polarity     int  `json:"polarity"`
+}
+
 func NewRecommendRepositoryDB(db *gorm.DB) RecommendRepository {
 	return &recommendRepositoryDB{db: db}
 }
 
+func (r *recommendRepositoryDB) Transaction(fn func(repo RecommendRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&recommendRepositoryDB{db: tx})
+	})
+}
+
 // Get all user preferences and blacklist settings
 func (r *recommendRepositoryDB) GetUserSettings(userID uint) ([]entities.PreferenceBlacklist, error) {
 	var settings []entities.PreferenceBlacklist
@@ -115,6 +137,8 @@ func (r *recommendRepositoryDB) GetDishReviewPage(dishID uint) (*entities.Dish,
 	return &dish, &restaurant, nil
 }
 
+// SubmitReview stores the review together with its pending processing job, so a review
+// can never exist without the job that extracts and normalizes it
 func (r *recommendRepositoryDB) SubmitReview(userID uint, dishID uint, resID uint, reviewText string) (uint, error) {
 	review := entities.UserReview{
 		UserID:  userID,
@@ -122,68 +146,142 @@ func (r *recommendRepositoryDB) SubmitReview(userID uint, dishID uint, resID uin
 		ResID:   resID,
 		UserRev: reviewText,
 	}
-	if err := r.db.Create(&review).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		job := entities.ReviewJob{
+			SourceID:   review.UserRevID,
+			SourceType: "user",
+			UserID:     userID,
+			DishID:     dishID,
+			ResID:      resID,
+			Status:     entities.JobPending,
+			NextRunAt:  now,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		return tx.Create(&job).Error
+	})
+	if err != nil {
 		return 0, err
 	}
 	return review.UserRevID, nil
 }
 
+func (r *recommendRepositoryDB) GetUserReviewByID(reviewID uint) (*entities.UserReview, error) {
+	var review entities.UserReview
+	if err := r.db.Where("user_rev_id = ?", reviewID).First(&review).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
 func (r *recommendRepositoryDB) HasReviewExtract(sourceID uint, sourceType string) (bool, error) {
 	var count int64
 	err := r.db.Table("review_extracts").Where("source_id = ? AND source_type = ?", sourceID, sourceType).Count(&count).Error
 	return count > 0, err
 }
 
-func (r *recommendRepositoryDB) HasNormalizedReview(sourceID uint) (bool, error) {
+func (r *recommendRepositoryDB) HasNormalizedReview(sourceID uint, sourceType string) (bool, error) {
 	var count int64
-	err := r.db.Table("review_dishes").Where("source_id = ?", sourceID).Count(&count).Error
+	err := r.db.Table("review_dishes").Where("source_id = ? AND source_type = ?", sourceID, sourceType).Count(&count).Error
 	return count > 0, err
 }
 
-// UpsertReviewExtract writes or updates an extraction result for a given (source_type, source_id)
-func (r *recommendRepositoryDB) UpsertReviewExtract(sourceID uint, sourceType string, dataExtract string) error {
-	// Try to find existing record first
-	var existing entities.ReviewExtract
-	err := r.db.Where("source_id = ? AND source_type = ?", sourceID, sourceType).First(&existing).Error
-
-	if err == gorm.ErrRecordNotFound {
-		// Create new record
-		rec := entities.ReviewExtract{
-			SourceID:    sourceID,
-			SourceType:  sourceType,
-			DataExtract: dataExtract,
+// SaveReviewExtract inserts rec as the current extraction of its source; earlier runs are kept
+// as history. The new row starts without normalization details until normalize runs.
+func (r *recommendRepositoryDB) SaveReviewExtract(rec *entities.ReviewExtract) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.ReviewExtract{}).
+			Where("source_id = ? AND source_type = ? AND is_current", rec.SourceID, rec.SourceType).
+			Update("is_current", false).Error; err != nil {
+			return err
 		}
-		return r.db.Create(&rec).Error
-	} else if err != nil {
-		return err
-	} else {
-		// Update existing record
-		return r.db.Model(&existing).Update("data_extract", dataExtract).Error
+		now := time.Now()
+		rec.ExtractID = 0
+		rec.IsCurrent = true
+		rec.ExtractedAt = &now
+		rec.NormalizedAt = nil
+		rec.KeywordsAttached = 0
+		return tx.Create(rec).Error
+	})
+}
+
+// GetReviewExtract returns the current extraction row including its processing details
+func (r *recommendRepositoryDB) GetReviewExtract(sourceID uint, sourceType string) (*entities.ReviewExtract, error) {
+	var rec entities.ReviewExtract
+	if err := r.db.Where("source_id = ? AND source_type = ? AND is_current", sourceID, sourceType).First(&rec).Error; err != nil {
+		return nil, err
 	}
+	return &rec, nil
 }
 
+// GetReviewExtractHistory returns every extraction run of a source, oldest first
+func (r *recommendRepositoryDB) GetReviewExtractHistory(sourceID uint, sourceType string) ([]entities.ReviewExtract, error) {
+	var recs []entities.ReviewExtract
+	err := r.db.Where("source_id = ? AND source_type = ?", sourceID, sourceType).Order("rev_ext_id").Find(&recs).Error
+	return recs, err
+}
+
+// MarkReviewNormalized records when normalization of the current extraction finished and how many keywords it attached
+func (r *recommendRepositoryDB) MarkReviewNormalized(sourceID uint, sourceType string, keywordsAttached int) error {
+	return r.db.Model(&entities.ReviewExtract{}).
+		Where("source_id = ? AND source_type = ? AND is_current", sourceID, sourceType).
+		Updates(map[string]interface{}{"normalized_at": time.Now(), "keywords_attached": keywordsAttached}).Error
+}
+
+// GetLatestReviewExtract returns the JSON of the current extraction
 func (r *recommendRepositoryDB) GetLatestReviewExtract(sourceID uint, sourceType string) (string, error) {
-	var rec entities.ReviewExtract
-	if err := r.db.Where("source_id = ? AND source_type = ?", sourceID, sourceType).Order("rev_ext_id DESC").First(&rec).Error; err != nil {
+	rec, err := r.GetReviewExtract(sourceID, sourceType)
+	if err != nil {
 		return "", err
 	}
 	return rec.DataExtract, nil
 }
 
 // EnsureReviewDish creates a review_dishes row if missing and returns it
-func (r *recommendRepositoryDB) EnsureReviewDish(sourceID uint, dishID uint, resID uint) (*entities.ReviewDish, error) {
+func (r *recommendRepositoryDB) EnsureReviewDish(sourceID uint, sourceType string, dishID uint, resID uint) (*entities.ReviewDish, error) {
 	// Try to find existing
 	var rd entities.ReviewDish
-	if err := r.db.Where("source_id = ? AND dish_id = ? AND res_id = ?", sourceID, dishID, resID).First(&rd).Error; err == nil {
+	if err := r.db.Where("source_id = ? AND source_type = ? AND dish_id = ? AND res_id = ?", sourceID, sourceType, dishID, resID).First(&rd).Error; err == nil {
 		return &rd, nil
 	}
-	rd = entities.ReviewDish{SourceID: sourceID, DishID: dishID, ResID: resID}
+	rd = entities.ReviewDish{SourceID: sourceID, SourceType: sourceType, DishID: dishID, ResID: resID}
 	if err := r.db.Create(&rd).Error; err != nil {
 		return nil, err
 	}
 	return &rd, nil
 }
 
+// FetchRestaurantDishNames lists the dish names and dish aliases of one restaurant
+func (r *recommendRepositoryDB) FetchRestaurantDishNames(resID uint) ([]DishNameRow, error) {
+	rows := []DishNameRow{}
+	err := r.db.Raw(`
+		SELECT d.dish_id, d.dish_name AS name FROM dishes d WHERE d.res_id = ?
+		UNION ALL
+		SELECT da.dish_id, da.alt_name AS name
+		FROM dish_aliases da JOIN dishes d ON d.dish_id = da.dish_id
+		WHERE d.res_id = ?`, resID, resID).Scan(&rows).Error
+	return rows, err
+}
+
+// RecordDishCandidate stores an unresolved dish mention; a mention already recorded for the
+// same review is left as is, so re-running normalization does not count it twice
+func (r *recommendRepositoryDB) RecordDishCandidate(c *entities.DishCandidate) error {
+	if c.Status == "" {
+		c.Status = entities.CandidatePending
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "res_id"}, {Name: "name_key"}, {Name: "source_id"}, {Name: "source_type"}},
+		DoNothing: true,
+	}).Create(c).Error
+}
+
 // FindKeywordByName resolves a keyword by its exact text; returns nil if not found
 func (r *recommendRepositoryDB) FindKeywordByName(name string) (*entities.Keyword, error) {
 	var kw entities.Keyword
@@ -203,6 +301,65 @@ func (r *recommendRepositoryDB) EnsureReviewDishKeyword(reviewDishID uint, keywo
 	return r.db.Create(&rdk).Error
 }
 
+// AttachReviewDishKeyword links a keyword to a review dish with its polarity and intensity and
+// bumps dish_keywords.frequency only when the link is new and not negated, in one transaction,
+// so re-running normalization is harmless
+func (r *recommendRepositoryDB) AttachReviewDishKeyword(reviewDishID uint, dishID uint, keywordID uint, polarity int, intensity float64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&entities.ReviewDishKeyword{}).
+			Where("review_dish_id = ? AND keyword_id = ?", reviewDishID, keywordID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		link := entities.ReviewDishKeyword{RDID: reviewDishID, KeywordID: keywordID, Polarity: polarity, Intensity: intensity}
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		// A negated keyword ("ไม่เค็ม") must not show up as one of the dish's keywords
+		if polarity < 0 {
+			return nil
+		}
+		return (&recommendRepositoryDB{db: tx}).BumpDishKeyword(dishID, keywordID, 1)
+	})
+}
+
+func (r *recommendRepositoryDB) GetReviewKeywordLinks(sourceID uint, sourceType string) ([]ReviewKeywordLink, error) {
+	var links []ReviewKeywordLink
+	err := r.db.Table("review_dish_keywords rdk").
+		Select("rdk.review_dish_id, rd.dish_id, rdk.keyword_id, rdk.polarity").
+		Joins("JOIN review_dishes rd ON rd.review_dish_id = rdk.review_dish_id").
+		Where("rd.source_id = ? AND rd.source_type = ?", sourceID, sourceType).
+		Scan(&links).Error
+	return links, err
+}
+
+// DetachReviewDishKeyword deletes the link and, when it counted toward dish_keywords.frequency,
+// takes that count back; a dish keyword left at zero is removed
+func (r *recommendRepositoryDB) DetachReviewDishKeyword(link ReviewKeywordLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("review_dish_id = ? AND keyword_id = ?", link.ReviewDishID, link.KeywordID).
+			Delete(&entities.ReviewDishKeyword{})
+		if res.Error != nil || res.RowsAffected == 0 || link.Polarity < 0 {
+			return res.Error
+		}
+		if err := tx.Model(&entities.DishKeyword{}).
+			Where("dish_id = ? AND keyword_id = ?", link.DishID, link.KeywordID).
+			UpdateColumn("frequency", gorm.Expr("GREATEST(COALESCE(frequency,0) - 1, 0)")).Error; err != nil {
+			return err
+		}
+		return tx.Where("dish_id = ? AND keyword_id = ? AND frequency <= 0", link.DishID, link.KeywordID).
+			Delete(&entities.DishKeyword{}).Error
+	})
+}
+
+func (r *recommendRepositoryDB) DeleteReviewDishes(sourceID uint, sourceType string) error {
+	return r.db.Where("source_id = ? AND source_type = ?", sourceID, sourceType).Delete(&entities.ReviewDish{}).Error
+}
+
 // FindOrCreateKeyword by name/category/sentiment
 func (r *recommendRepositoryDB) FindOrCreateKeyword(name string, category string, sentiment string) (*entities.Keyword, error) {
 	var kw entities.Keyword
@@ -234,30 +391,53 @@ func (r *recommendRepositoryDB) BumpDishKeyword(dishID uint, keywordID uint, del
 func (r *recommendRepositoryDB) RecomputeScoresAndRestaurants() error {
 	// Positive/Negative per review aggregation -> update dishes
 	if err := r.db.Exec(`
-		WITH per_review AS (
+		-- A review counts once per side; the weighted sums use its strongest keyword on that
+		-- side. Negative polarity flips the keyword's sentiment ("ไม่เค็ม" is positive)
+		WITH per_keyword AS (
 			SELECT rd.dish_id, rd.review_dish_id,
-				   MAX(CASE WHEN k.sentiment = 'positive' THEN 1 ELSE 0 END) AS has_pos,
-				   MAX(CASE WHEN k.sentiment = 'negative' THEN 1 ELSE 0 END) AS has_neg
+				   CASE WHEN COALESCE(rdk.polarity, 1) >= 0 THEN k.sentiment
+				        WHEN k.sentiment = 'positive' THEN 'negative'
+				        WHEN k.sentiment = 'negative' THEN 'positive'
+				        ELSE k.sentiment END AS sentiment,
+				   COALESCE(rdk.intensity, 1) AS intensity
 			FROM review_dishes rd
 			LEFT JOIN review_dish_keywords rdk ON rdk.review_dish_id = rd.review_dish_id
 			LEFT JOIN keywords k ON k.keyword_id = rdk.keyword_id
-			GROUP BY rd.dish_id, rd.review_dish_id
+		), per_review AS (
+			SELECT dish_id, review_dish_id,
+				   MAX(CASE WHEN sentiment = 'positive' THEN 1 ELSE 0 END) AS has_pos,
+				   MAX(CASE WHEN sentiment = 'negative' THEN 1 ELSE 0 END) AS has_neg,
+				   MAX(CASE WHEN sentiment = 'positive' THEN intensity ELSE 0 END) AS pos_weight,
+				   MAX(CASE WHEN sentiment = 'negative' THEN intensity ELSE 0 END) AS neg_weight
+			FROM per_keyword
+			GROUP BY dish_id, review_dish_id
 		), agg AS (
 			SELECT dish_id,
-				   SUM(has_pos) AS pos,
-				   SUM(has_neg) AS neg,
-				   COUNT(*)      AS total_reviews
+				   SUM(has_pos)    AS pos,
+				   SUM(has_neg)    AS neg,
+				   SUM(pos_weight) AS pos_weight,
+				   SUM(neg_weight) AS neg_weight,
+				   COUNT(*)        AS total_reviews
 			FROM per_review
 			GROUP BY dish_id
 		)
 		UPDATE dishes d
-		SET positive_score = COALESCE(a.pos, 0),
-			negative_score = COALESCE(a.neg, 0),
-			total_score    = COALESCE(a.total_reviews, 0)
+		SET positive_score    = COALESCE(a.pos, 0),
+			negative_score    = COALESCE(a.neg, 0),
+			weighted_positive = COALESCE(a.pos_weight, 0),
+			weighted_negative = COALESCE(a.neg_weight, 0),
+			total_score       = COALESCE(a.total_reviews, 0)
 		FROM agg a
 		WHERE a.dish_id = d.dish_id`).Error; err != nil {
 		return err
 	}
+	// Dishes whose last review was detached (re-normalized elsewhere) are not in agg
+	if err := r.db.Exec(`
+		UPDATE dishes d SET positive_score = 0, negative_score = 0, weighted_positive = 0, weighted_negative = 0, total_score = 0
+		WHERE (d.positive_score <> 0 OR d.negative_score <> 0 OR d.total_score <> 0)
+		  AND NOT EXISTS (SELECT 1 FROM review_dishes rd WHERE rd.dish_id = d.dish_id)`).Error; err != nil {
+		return err
+	}
 	// Update restaurant menu_size
 	if err := r.db.Exec(`
 		UPDATE restaurants r SET menu_size=COALESCE(s.cnt,0)
@@ -320,6 +500,17 @@ func (r *recommendRepositoryDB) GetKeywordsByCategory(categories []string) ([]en
 	return keywords, result.Error
 }
 
+func (r *recommendRepositoryDB) FetchDictionaryWords() ([]string, error) {
+	var words []string
+	err := r.db.Raw(`
+		SELECT dish_name FROM dishes
+		UNION SELECT alt_name FROM dish_aliases
+		UNION SELECT keyword FROM keywords
+		UNION SELECT alt_word FROM keyword_aliases`).
+		Scan(&words).Error
+	return words, err
+}
+
 // FetchKeywordAliases loads all alt_word -> base keyword mappings
 func (r *recommendRepositoryDB) FetchKeywordAliases() (map[string]string, error) {
 	rows := []struct {
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+)
+
+// ErrReviewJobLockLost means the job was recovered and possibly claimed again since this worker
+// claimed it, so the worker's outcome was not recorded
+var ErrReviewJobLockLost = errors.New("review job is no longer locked by this worker")
+
+type ReviewJobRepository interface {
+	// EnqueueReviewJob inserts a pending job; a review that already has a job is left as is
+	EnqueueReviewJob(job *entities.ReviewJob) error
+	// ClaimReviewJob atomically moves the oldest due pending job to running, locks it for worker
+	// and bumps its attempts. Returns nil when nothing is due.
+	ClaimReviewJob(now time.Time, worker string) (*entities.ReviewJob, error)
+	// CompleteReviewJob and FailReviewJob only update the job while it still holds the lock taken
+	// by ClaimReviewJob; otherwise they return ErrReviewJobLockLost
+	CompleteReviewJob(job *entities.ReviewJob) error
+	// FailReviewJob records lastError; the job is retried at retryAt, or marked failed when retryAt is nil
+	FailReviewJob(job *entities.ReviewJob, lastError string, retryAt *time.Time) error
+	// RecoverStuckReviewJobs returns running jobs locked before cutoff to pending, or to failed
+	// when they have used up maxAttempts
+	RecoverStuckReviewJobs(cutoff time.Time, maxAttempts int) (int64, error)
+	GetReviewJobBySource(sourceID uint, sourceType string) (*entities.ReviewJob, error)
+}
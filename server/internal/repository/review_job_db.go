@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type reviewJobRepositoryDB struct {
+	db *gorm.DB
+}
+
+func NewReviewJobRepositoryDB(db *gorm.DB) ReviewJobRepository {
+	return &reviewJobRepositoryDB{db: db}
+}
+
+func (r *reviewJobRepositoryDB) EnqueueReviewJob(job *entities.ReviewJob) error {
+	now := time.Now()
+	if job.Status == "" {
+		job.Status = entities.JobPending
+	}
+	if job.NextRunAt.IsZero() {
+		job.NextRunAt = now
+	}
+	job.CreatedAt, job.UpdatedAt = now, now
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source_id"}, {Name: "source_type"}},
+		DoNothing: true,
+	}).Create(job).Error
+}
+
+func (r *reviewJobRepositoryDB) ClaimReviewJob(now time.Time, worker string) (*entities.ReviewJob, error) {
+	var job entities.ReviewJob
+	// SKIP LOCKED lets several workers (and replicas) claim different jobs without blocking
+	err := r.db.Raw(`
+		UPDATE review_jobs SET status = ?, attempts = attempts + 1, locked_at = ?, locked_by = ?, started_at = ?, updated_at = ?
+		WHERE job_id = (
+			SELECT job_id FROM review_jobs
+			WHERE status = ? AND next_run_at <= ?
+			ORDER BY next_run_at, job_id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`,
+		entities.JobRunning, now, worker, now, now, entities.JobPending, now).Scan(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	if job.JobID == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+func (r *reviewJobRepositoryDB) CompleteReviewJob(job *entities.ReviewJob) error {
+	now := time.Now()
+	return r.updateLocked(job, map[string]interface{}{
+		"status":      entities.JobSucceeded,
+		"last_error":  "",
+		"locked_at":   nil,
+		"locked_by":   "",
+		"finished_at": now,
+		"updated_at":  now,
+	})
+}
+
+func (r *reviewJobRepositoryDB) FailReviewJob(job *entities.ReviewJob, lastError string, retryAt *time.Time) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"last_error": lastError,
+		"locked_at":  nil,
+		"locked_by":  "",
+		"updated_at": now,
+	}
+	if retryAt != nil {
+		updates["status"] = entities.JobPending
+		updates["next_run_at"] = *retryAt
+	} else {
+		updates["status"] = entities.JobFailed
+		updates["finished_at"] = now
+	}
+	return r.updateLocked(job, updates)
+}
+
+// updateLocked applies updates only while the job still carries the lock from its claim. A job
+// recovered as stuck and claimed again has a new locked_by/locked_at, so a late worker cannot
+// overwrite the newer attempt.
+func (r *reviewJobRepositoryDB) updateLocked(job *entities.ReviewJob, updates map[string]interface{}) error {
+	if job.LockedAt == nil {
+		return ErrReviewJobLockLost
+	}
+	res := r.db.Model(&entities.ReviewJob{}).
+		Where("job_id = ? AND status = ? AND locked_by = ? AND locked_at = ?", job.JobID, entities.JobRunning, job.LockedBy, *job.LockedAt).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrReviewJobLockLost
+	}
+	return nil
+}
+
+func (r *reviewJobRepositoryDB) RecoverStuckReviewJobs(cutoff time.Time, maxAttempts int) (int64, error) {
+	now := time.Now()
+	const reason = "worker stopped while the job was running"
+	var recovered int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		failed := tx.Model(&entities.ReviewJob{}).
+			Where("status = ? AND locked_at < ? AND attempts >= ?", entities.JobRunning, cutoff, maxAttempts).
+			Updates(map[string]interface{}{
+				"status": entities.JobFailed, "last_error": reason, "locked_at": nil, "locked_by": "", "finished_at": now, "updated_at": now,
+			})
+		if failed.Error != nil {
+			return failed.Error
+		}
+		retried := tx.Model(&entities.ReviewJob{}).
+			Where("status = ? AND locked_at < ?", entities.JobRunning, cutoff).
+			Updates(map[string]interface{}{
+				"status": entities.JobPending, "last_error": reason, "locked_at": nil, "locked_by": "", "next_run_at": now, "updated_at": now,
+			})
+		if retried.Error != nil {
+			return retried.Error
+		}
+		recovered = failed.RowsAffected + retried.RowsAffected
+		return nil
+	})
+	return recovered, err
+}
+
+func (r *reviewJobRepositoryDB) GetReviewJobBySource(sourceID uint, sourceType string) (*entities.ReviewJob, error) {
+	var job entities.ReviewJob
+	if err := r.db.Where("source_id = ? AND source_type = ?", sourceID, sourceType).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -1,6 +1,10 @@
 package repository
 
-import "github.com/bestchayapol/DishDive/internal/entities"
+import (
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/entities"
+)
 
 type UserRepository interface {
 	GetAllUser() ([]entities.User, error)
@@ -18,4 +22,28 @@ type UserRepository interface {
 
 	CreateUser(user *entities.User) error                      //Register
 	GetUserByUsername(userName string) (*entities.User, error) //Login
+
+	////////////////////////////////////////////////////////////////////
+	// Sessions (refresh tokens)
+
+	CreateRefreshToken(token *entities.RefreshToken) error
+	GetRefreshTokenByHash(tokenHash string) (*entities.RefreshToken, error)
+	RotateRefreshToken(current *entities.RefreshToken, next *entities.RefreshToken) error
+	RevokeRefreshTokenFamily(familyID string) error
+	RevokeAllRefreshTokens(userID uint) error
+	HasActiveSession(userID uint, familyID string) (bool, error)
+	CountActiveSessions() (map[uint]int64, error)
+	RevokeOtherRefreshTokens(userID uint, keepFamilyID string) error
+
+	////////////////////////////////////////////////////////////////////
+	// Credentials and account lifecycle
+
+	UpdatePasswordHash(userID uint, passwordHash string) error
+	GetLoginLock(userID uint, ip string) (lockedUntil *time.Time, err error)
+	RecordFailedLogin(userID uint, ip string, maxFailures int, lockUntil time.Time) (lockedUntil *time.Time, err error)
+	ResetFailedLogins(userID uint, ip string) error
+	CreatePasswordResetToken(token *entities.PasswordResetToken) error
+	ConsumePasswordResetToken(tokenHash string) (*entities.PasswordResetToken, error)
+	InvalidatePasswordResetTokens(userID uint) error
+	DeleteUserCascade(userID uint) (affectedDishIDs []uint, err error)
 }
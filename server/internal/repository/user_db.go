@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/bestchayapol/DishDive/internal/entities"
 
@@ -118,3 +120,259 @@ func (r userRepositoryDB) GetUserByUsername(username string) (*entities.User, er
 	}
 	return &user, nil
 }
+
+/////////////////////////////////////////////////////////////////////////////////////////////
+// Sessions (refresh tokens)
+
+func (r userRepositoryDB) CreateRefreshToken(token *entities.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r userRepositoryDB) GetRefreshTokenByHash(tokenHash string) (*entities.RefreshToken, error) {
+	var token entities.RefreshToken
+	result := r.db.Where("token_hash = ?", tokenHash).First(&token)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken stores next and marks current as revoked/replaced in one transaction.
+// The revoked_at IS NULL guard makes concurrent rotations of the same token fail.
+func (r userRepositoryDB) RotateRefreshToken(current *entities.RefreshToken, next *entities.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&entities.RefreshToken{}).
+			Where("token_id = ? AND revoked_at IS NULL", current.TokenID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"replaced_by_id": next.TokenID,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r userRepositoryDB) RevokeRefreshTokenFamily(familyID string) error {
+	return r.db.Model(&entities.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r userRepositoryDB) RevokeAllRefreshTokens(userID uint) error {
+	return r.db.Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// HasActiveSession reports whether the session family still has an unrevoked, unexpired token
+func (r userRepositoryDB) HasActiveSession(userID uint, familyID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, familyID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountActiveSessions returns user_id -> number of live session families
+func (r userRepositoryDB) CountActiveSessions() (map[uint]int64, error) {
+	rows := []struct {
+		UserID   uint
+		Sessions int64
+	}{}
+	err := r.db.Model(&entities.RefreshToken{}).
+		Select("user_id, COUNT(DISTINCT family_id) AS sessions").
+		Where("revoked_at IS NULL AND expires_at > ?", time.Now()).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		m[row.UserID] = row.Sessions
+	}
+	return m, nil
+}
+
+func (r userRepositoryDB) RevokeOtherRefreshTokens(userID uint, keepFamilyID string) error {
+	return r.db.Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////
+// Credentials and account lifecycle
+
+// UpdatePasswordHash stores a new password hash and lifts the login lockouts of every IP
+func (r userRepositoryDB) UpdatePasswordHash(userID uint, passwordHash string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.User{}).Where("user_id = ?", userID).Update("password_hash", passwordHash).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&entities.LoginFailure{}).Error
+	})
+}
+
+// GetLoginLock returns until when the account is locked for ip, nil when it is not
+func (r userRepositoryDB) GetLoginLock(userID uint, ip string) (*time.Time, error) {
+	var failure entities.LoginFailure
+	err := r.db.Where("user_id = ? AND ip = ?", userID, ip).Limit(1).Find(&failure).Error
+	return failure.LockedUntil, err
+}
+
+// RecordFailedLogin counts a wrong password for the account from ip. When the count reaches
+// maxFailures the account is locked for that ip until lockUntil and the counter starts over.
+// Returns the resulting locked_until.
+func (r userRepositoryDB) RecordFailedLogin(userID uint, ip string, maxFailures int, lockUntil time.Time) (*time.Time, error) {
+	var row struct {
+		LockedUntil *time.Time
+	}
+	err := r.db.Raw(`
+		INSERT INTO login_failures AS lf (user_id, ip, failed_count, locked_until)
+		VALUES (?, ?, CASE WHEN 1 >= ? THEN 0 ELSE 1 END, CASE WHEN 1 >= ? THEN ?::timestamptz END)
+		ON CONFLICT (user_id, ip) DO UPDATE SET
+			locked_until = CASE WHEN lf.failed_count + 1 >= ? THEN ? ELSE lf.locked_until END,
+			failed_count = CASE WHEN lf.failed_count + 1 >= ? THEN 0 ELSE lf.failed_count + 1 END
+		RETURNING locked_until`,
+		userID, ip, maxFailures, maxFailures, lockUntil, maxFailures, lockUntil, maxFailures).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return row.LockedUntil, nil
+}
+
+// ResetFailedLogins clears the lockout state of the account for ip after a successful login
+func (r userRepositoryDB) ResetFailedLogins(userID uint, ip string) error {
+	return r.db.Where("user_id = ? AND ip = ?", userID, ip).Delete(&entities.LoginFailure{}).Error
+}
+
+func (r userRepositoryDB) CreatePasswordResetToken(token *entities.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// ErrResetTokenUnusable is returned when a reset token exists but was already used or has expired
+var ErrResetTokenUnusable = errors.New("reset token already used or expired")
+
+// ConsumePasswordResetToken marks the token used; the used_at IS NULL guard makes it single-use under concurrency
+func (r userRepositoryDB) ConsumePasswordResetToken(tokenHash string) (*entities.PasswordResetToken, error) {
+	var token entities.PasswordResetToken
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	res := r.db.Model(&entities.PasswordResetToken{}).
+		Where("reset_id = ? AND used_at IS NULL AND expires_at > ?", token.ResetID, now).
+		Update("used_at", now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrResetTokenUnusable
+	}
+	token.UsedAt = &now
+	return &token, nil
+}
+
+func (r userRepositoryDB) InvalidatePasswordResetTokens(userID uint) error {
+	return r.db.Model(&entities.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+// DeleteUserCascade removes the user and everything hanging off the account, undoing the
+// user's contribution to dish_keywords. Returns the dishes whose scores must be recomputed.
+func (r userRepositoryDB) DeleteUserCascade(userID uint) ([]uint, error) {
+	var affected []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		userReviewDishes := `
+			SELECT rd.review_dish_id, rd.dish_id
+			FROM review_dishes rd
+			JOIN user_reviews ur ON ur.user_rev_id = rd.source_id AND ur.res_id = rd.res_id
+			WHERE rd.source_type = 'user' AND ur.user_id = ?`
+
+		if err := tx.Raw(`SELECT DISTINCT dish_id FROM (`+userReviewDishes+`) x`, userID).Scan(&affected).Error; err != nil {
+			return err
+		}
+
+		// Take back the keyword frequencies this user's reviews contributed
+		if err := tx.Exec(`
+			UPDATE dish_keywords dk
+			SET frequency = GREATEST(dk.frequency - x.cnt, 0)
+			FROM (
+				SELECT urd.dish_id, rdk.keyword_id, COUNT(*) AS cnt
+				FROM review_dish_keywords rdk
+				JOIN (`+userReviewDishes+`) urd ON urd.review_dish_id = rdk.review_dish_id
+				WHERE rdk.polarity >= 0
+				GROUP BY urd.dish_id, rdk.keyword_id
+			) x
+			WHERE dk.dish_id = x.dish_id AND dk.keyword_id = x.keyword_id`, userID).Error; err != nil {
+			return err
+		}
+		if len(affected) > 0 {
+			if err := tx.Where("dish_id IN ? AND frequency <= 0", affected).Delete(&entities.DishKeyword{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec(`
+			DELETE FROM review_dish_keywords
+			WHERE review_dish_id IN (SELECT review_dish_id FROM (`+userReviewDishes+`) x)`, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			DELETE FROM review_dishes
+			WHERE review_dish_id IN (SELECT review_dish_id FROM (`+userReviewDishes+`) x)`, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			DELETE FROM review_extracts
+			WHERE source_type = 'user' AND source_id IN (SELECT user_rev_id FROM user_reviews WHERE user_id = ?)`, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			DELETE FROM review_jobs
+			WHERE source_type = 'user' AND source_id IN (SELECT user_rev_id FROM user_reviews WHERE user_id = ?)`, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			DELETE FROM dish_candidates
+			WHERE source_type = 'user' AND source_id IN (SELECT user_rev_id FROM user_reviews WHERE user_id = ?)`, userID).Error; err != nil {
+			return err
+		}
+
+		// Dishes left without any review would keep stale scores (the recompute only touches dishes with reviews)
+		if len(affected) > 0 {
+			if err := tx.Exec(`
+				UPDATE dishes d SET positive_score = 0, negative_score = 0, weighted_positive = 0, weighted_negative = 0, total_score = 0
+				WHERE d.dish_id IN ? AND NOT EXISTS (SELECT 1 FROM review_dishes rd WHERE rd.dish_id = d.dish_id)`, affected).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, model := range []interface{}{
+			&entities.UserReview{},
+			&entities.Favorite{},
+			&entities.PreferenceBlacklist{},
+			&entities.RefreshToken{},
+			&entities.PasswordResetToken{},
+			&entities.LoginFailure{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("user_id = ?", userID).Delete(&entities.User{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return affected, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/repository"
+)
+
+// AdminService manages the catalog (restaurants, locations, keywords, aliases, whitelist)
+// and user roles. Every mutation is recorded in admin_audit_logs under adminID.
+type AdminService interface {
+	// Restaurants and locations
+	GetRestaurants() ([]entities.Restaurant, error)
+	CreateRestaurant(adminID uint, req dtos.AdminRestaurantRequest) (*entities.Restaurant, error)
+	UpdateRestaurant(adminID uint, resID uint, req dtos.AdminRestaurantRequest) (*entities.Restaurant, error)
+	AddOrUpdateLocation(adminID uint, req dtos.RestaurantLocationResponse) (*entities.RestaurantLocation, error)
+	DeleteLocation(adminID uint, rlID uint) error
+
+	// Keywords and aliases
+	GetKeywords(category string) ([]entities.Keyword, error)
+	CreateKeyword(adminID uint, req dtos.AdminKeywordRequest) (*entities.Keyword, error)
+	UpdateKeyword(adminID uint, keywordID uint, req dtos.AdminKeywordRequest) (*entities.Keyword, error)
+	RecategorizeKeywords(adminID uint, dryRun bool) (*dtos.AdminRecategorizeResponse, error)
+	GetDishAliases(dishID uint) ([]entities.DishAlias, error)
+	AddDishAlias(adminID uint, req dtos.AdminDishAliasRequest) (*entities.DishAlias, error)
+	DeleteDishAlias(adminID uint, daID uint) error
+	GetKeywordAliases(keywordID uint) ([]entities.KeywordAlias, error)
+	AddKeywordAlias(adminID uint, req dtos.AdminKeywordAliasRequest) (*entities.KeywordAlias, error)
+	DeleteKeywordAlias(adminID uint, kaID uint) error
+
+	// Dish candidates
+	GetDishCandidates(status string, resID uint) ([]repository.DishCandidateGroup, error)
+	ResolveDishCandidate(ctx context.Context, adminID uint, req dtos.AdminResolveDishCandidateRequest) (*dtos.AdminResolveDishCandidateResponse, error)
+
+	// Review extractions
+	SetCurrentReviewExtract(ctx context.Context, adminID uint, req dtos.AdminSetCurrentReviewExtractRequest) (*dtos.AdminSetCurrentReviewExtractResponse, error)
+
+	// Restaurant whitelist
+	GetWhitelist() ([]entities.RestaurantWhitelistEntry, error)
+	AddToWhitelist(adminID uint, req dtos.AdminWhitelistRequest) (*entities.RestaurantWhitelistEntry, error)
+	RemoveFromWhitelist(adminID uint, wlID uint) error
+
+	// Users and audit
+	SetUserRole(adminID uint, userID uint, req dtos.AdminSetUserRoleRequest) error
+	GetAuditLogs(limit int, offset int) ([]entities.AdminAuditLog, error)
+}
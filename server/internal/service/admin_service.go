@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/normalize"
+	"github.com/bestchayapol/DishDive/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Audit log actions and target types
+const (
+	auditCreate       = "create"
+	auditUpdate       = "update"
+	auditDelete       = "delete"
+	auditRecategorize = "recategorize"
+
+	targetRestaurant    = "restaurant"
+	targetLocation      = "location"
+	targetKeyword       = "keyword"
+	targetDishAlias     = "dish_alias"
+	targetDishCandidate = "dish_candidate"
+	targetKeywordAlias  = "keyword_alias"
+	targetReviewExtract = "review_extract"
+	targetWhitelist     = "whitelist"
+	targetUserRole      = "user_role"
+)
+
+const maxAuditLogPage = 200
+
+// Keyword categories kept outside the categories file, and the sentiments a keyword may have
+var (
+	fixedKeywordCategories = map[string]bool{"system": true, "cuisine": true, "restriction": true, "others": true}
+	keywordSentiments      = map[string]bool{"": true, "positive": true, "negative": true}
+)
+
+// keywordCategory resolves an admin-supplied category to a configured category or a fixed one
+func keywordCategory(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if fixedKeywordCategories[name] {
+		return name, true
+	}
+	return normalize.CurrentCategories().Canonical(name)
+}
+
+type adminService struct {
+	adminRepo     repository.AdminRepository
+	foodRepo      repository.FoodRepository
+	recommendRepo repository.RecommendRepository // normalizes reviews again after a dish candidate is resolved or the current extraction changes
+}
+
+func NewAdminService(adminRepo repository.AdminRepository, foodRepo repository.FoodRepository, recommendRepo repository.RecommendRepository) AdminService {
+	return &adminService{adminRepo: adminRepo, foodRepo: foodRepo, recommendRepo: recommendRepo}
+}
+
+// newAudit builds the audit entry for a mutation; the request body is kept as its JSON payload
+func newAudit(adminID uint, action string, targetType string, payload interface{}) *entities.AdminAuditLog {
+	audit := &entities.AdminAuditLog{AdminID: adminID, Action: action, TargetType: targetType}
+	if payload != nil {
+		if b, err := json.Marshal(payload); err == nil {
+			audit.Payload = string(b)
+		}
+	}
+	return audit
+}
+
+// mapAdminError turns repository errors into HTTP errors for the given target
+func mapAdminError(err error, target string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fiber.NewError(fiber.StatusNotFound, target+" not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fiber.NewError(fiber.StatusConflict, target+" already exists")
+	}
+	return err
+}
+
+func trimmed(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(*s)
+}
+
+// Restaurants
+
+func (s *adminService) GetRestaurants() ([]entities.Restaurant, error) {
+	return s.adminRepo.GetRestaurants()
+}
+
+func (s *adminService) CreateRestaurant(adminID uint, req dtos.AdminRestaurantRequest) (*entities.Restaurant, error) {
+	if trimmed(req.ResName) == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "res_name is required")
+	}
+	restaurant := &entities.Restaurant{}
+	applyRestaurantRequest(restaurant, req)
+	if err := s.adminRepo.SaveRestaurant(restaurant, newAudit(adminID, auditCreate, targetRestaurant, req)); err != nil {
+		return nil, mapAdminError(err, targetRestaurant)
+	}
+	return restaurant, nil
+}
+
+func (s *adminService) UpdateRestaurant(adminID uint, resID uint, req dtos.AdminRestaurantRequest) (*entities.Restaurant, error) {
+	if req.ResName != nil && trimmed(req.ResName) == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "res_name cannot be empty")
+	}
+	restaurant, err := s.adminRepo.GetRestaurantByID(resID)
+	if err != nil {
+		return nil, mapAdminError(err, targetRestaurant)
+	}
+	applyRestaurantRequest(restaurant, req)
+	if err := s.adminRepo.SaveRestaurant(restaurant, newAudit(adminID, auditUpdate, targetRestaurant, req)); err != nil {
+		return nil, mapAdminError(err, targetRestaurant)
+	}
+	return restaurant, nil
+}
+
+func applyRestaurantRequest(restaurant *entities.Restaurant, req dtos.AdminRestaurantRequest) {
+	if req.ResName != nil {
+		restaurant.ResName = trimmed(req.ResName)
+	}
+	if req.ResCuisine != nil {
+		restaurant.ResCuisine = req.ResCuisine
+	}
+	if req.ResRestriction != nil {
+		restaurant.ResRestriction = req.ResRestriction
+	}
+	if req.MenuSize != nil {
+		restaurant.MenuSize = *req.MenuSize
+	}
+	if req.ImageTag != nil {
+		restaurant.ImageTag = req.ImageTag
+	}
+}
+
+// Locations
+
+func (s *adminService) AddOrUpdateLocation(adminID uint, req dtos.RestaurantLocationResponse) (*entities.RestaurantLocation, error) {
+	if strings.TrimSpace(req.LocationName) == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "location_name is required")
+	}
+	if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "latitude/longitude out of range")
+	}
+	if _, err := s.adminRepo.GetRestaurantByID(req.ResID); err != nil {
+		return nil, mapAdminError(err, targetRestaurant)
+	}
+	action := auditCreate
+	if req.RLID != 0 {
+		action = auditUpdate
+		if _, err := s.adminRepo.GetLocationByID(req.RLID); err != nil {
+			return nil, mapAdminError(err, targetLocation)
+		}
+	}
+	location := &entities.RestaurantLocation{
+		RLID:         req.RLID,
+		ResID:        req.ResID,
+		LocationName: strings.TrimSpace(req.LocationName),
+		Address:      strings.TrimSpace(req.Address),
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+	}
+	if err := s.adminRepo.SaveLocation(location, newAudit(adminID, action, targetLocation, req)); err != nil {
+		return nil, mapAdminError(err, targetLocation)
+	}
+	return location, nil
+}
+
+func (s *adminService) DeleteLocation(adminID uint, rlID uint) error {
+	return mapAdminError(s.adminRepo.DeleteLocation(rlID, newAudit(adminID, auditDelete, targetLocation, nil)), targetLocation)
+}
+
+// Keywords
+
+func (s *adminService) GetKeywords(category string) ([]entities.Keyword, error) {
+	return s.adminRepo.GetKeywords(category)
+}
+
+func (s *adminService) CreateKeyword(adminID uint, req dtos.AdminKeywordRequest) (*entities.Keyword, error) {
+	if trimmed(req.Keyword) == "" || trimmed(req.Category) == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "keyword and category are required")
+	}
+	keyword := &entities.Keyword{}
+	if err := applyKeywordRequest(keyword, req); err != nil {
+		return nil, err
+	}
+	if err := s.adminRepo.SaveKeyword(keyword, newAudit(adminID, auditCreate, targetKeyword, req)); err != nil {
+		return nil, mapAdminError(err, targetKeyword)
+	}
+	return keyword, nil
+}
+
+func (s *adminService) UpdateKeyword(adminID uint, keywordID uint, req dtos.AdminKeywordRequest) (*entities.Keyword, error) {
+	keyword, err := s.adminRepo.GetKeywordByID(keywordID)
+	if err != nil {
+		return nil, mapAdminError(err, targetKeyword)
+	}
+	if err := applyKeywordRequest(keyword, req); err != nil {
+		return nil, err
+	}
+	if err := s.adminRepo.SaveKeyword(keyword, newAudit(adminID, auditUpdate, targetKeyword, req)); err != nil {
+		return nil, mapAdminError(err, targetKeyword)
+	}
+	return keyword, nil
+}
+
+func applyKeywordRequest(keyword *entities.Keyword, req dtos.AdminKeywordRequest) error {
+	if req.Keyword != nil {
+		name := normalize.CleanText(*req.Keyword)
+		if name == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "keyword cannot be empty")
+		}
+		keyword.Keyword = name
+	}
+	if req.Category != nil {
+		category, ok := keywordCategory(trimmed(req.Category))
+		if !ok {
+			return fiber.NewError(fiber.StatusBadRequest, "unknown keyword category")
+		}
+		keyword.Category = category
+	}
+	if req.Sentiment != nil {
+		sentiment := strings.ToLower(trimmed(req.Sentiment))
+		if !keywordSentiments[sentiment] {
+			return fiber.NewError(fiber.StatusBadRequest, "sentiment must be positive, negative or empty")
+		}
+		keyword.Sentiment = sentiment
+	}
+	return nil
+}
+
+// RecategorizeKeywords files every keyword outside the fixed categories under the current
+// categories file. Keywords the file does not match keep a configured category (under its
+// current name if they use an alias) and fall back to "others" otherwise.
+func (s *adminService) RecategorizeKeywords(adminID uint, dryRun bool) (*dtos.AdminRecategorizeResponse, error) {
+	keywords, err := s.adminRepo.GetKeywords("")
+	if err != nil {
+		return nil, err
+	}
+	resp := &dtos.AdminRecategorizeResponse{DryRun: dryRun, Changes: []dtos.AdminKeywordCategoryChange{}}
+	moves := map[uint]string{}
+	for _, kw := range keywords {
+		from := strings.ToLower(strings.TrimSpace(kw.Category))
+		if from != "others" && fixedKeywordCategories[from] {
+			continue
+		}
+		resp.Scanned++
+		to, _ := normalize.Categorize(kw.Keyword, kw.Sentiment)
+		if to == "others" {
+			if current, ok := normalize.CurrentCategories().Canonical(from); ok {
+				to = current
+			}
+		}
+		if to == kw.Category {
+			continue
+		}
+		moves[kw.KeywordID] = to
+		resp.Changes = append(resp.Changes, dtos.AdminKeywordCategoryChange{
+			KeywordID: kw.KeywordID, Keyword: kw.Keyword, Sentiment: kw.Sentiment, From: kw.Category, To: to,
+		})
+	}
+	if dryRun || len(moves) == 0 {
+		return resp, nil
+	}
+
+	merged, err := s.adminRepo.RecategorizeKeywords(moves, newAudit(adminID, auditRecategorize, targetKeyword, resp.Changes))
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range resp.Changes {
+		if into, ok := merged[c.KeywordID]; ok {
+			resp.Changes[i].MergedInto = &into
+		}
+	}
+	return resp, nil
+}
+
+// Aliases
+
+func (s *adminService) GetDishAliases(dishID uint) ([]entities.DishAlias, error) {
+	return s.adminRepo.GetDishAliases(dishID)
+}
+
+func (s *adminService) AddDishAlias(adminID uint, req dtos.AdminDishAliasRequest) (*entities.DishAlias, error) {
+	altName := strings.TrimSpace(req.AltName)
+	if req.DishID == 0 || altName == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "dish_id and alt_name are required")
+	}
+	if _, err := s.foodRepo.GetDishByID(req.DishID); err != nil {
+		return nil, mapAdminError(err, "dish")
+	}
+	alias := &entities.DishAlias{DishID: req.DishID, AltName: altName}
+	if err := s.adminRepo.CreateDishAlias(alias, newAudit(adminID, auditCreate, targetDishAlias, req)); err != nil {
+		return nil, mapAdminError(err, targetDishAlias)
+	}
+	return alias, nil
+}
+
+func (s *adminService) DeleteDishAlias(adminID uint, daID uint) error {
+	return mapAdminError(s.adminRepo.DeleteDishAlias(daID, newAudit(adminID, auditDelete, targetDishAlias, nil)), targetDishAlias)
+}
+
+// Dish candidates
+
+func (s *adminService) GetDishCandidates(status string, resID uint) ([]repository.DishCandidateGroup, error) {
+	if status == "" {
+		status = entities.CandidatePending
+	}
+	return s.adminRepo.GetDishCandidates(status, resID)
+}
+
+// ResolveDishCandidate settles every pending mention of a candidate dish name at a restaurant.
+// Unless dismissed, the reviews that mentioned it are normalized again so their keywords reach
+// the dish; a review that fails is counted and left for the next run.
+func (s *adminService) ResolveDishCandidate(ctx context.Context, adminID uint, req dtos.AdminResolveDishCandidateRequest) (*dtos.AdminResolveDishCandidateResponse, error) {
+	name := normalize.CleanText(req.Name)
+	if req.ResID == 0 || name == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "res_id and name are required")
+	}
+	key := normalize.DishKey(name)
+	res := repository.DishCandidateResolution{Status: entities.CandidateResolved}
+	switch {
+	case req.Dismiss:
+		res.Status = entities.CandidateDismissed
+	case req.DishID != nil:
+		dish, err := s.foodRepo.GetDishByID(*req.DishID)
+		if err != nil {
+			return nil, mapAdminError(err, "dish")
+		}
+		if dish.ResID != req.ResID {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "dish belongs to another restaurant")
+		}
+		res.DishID = dish.DishID
+		known := normalize.DishKey(dish.DishName) == key
+		aliases, err := s.adminRepo.GetDishAliases(dish.DishID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range aliases {
+			known = known || normalize.DishKey(a.AltName) == key
+		}
+		if !known {
+			res.Alias = &entities.DishAlias{AltName: name}
+		}
+	default:
+		if _, err := s.adminRepo.GetRestaurantByID(req.ResID); err != nil {
+			return nil, mapAdminError(err, targetRestaurant)
+		}
+		res.NewDish = &entities.Dish{ResID: req.ResID, DishName: name}
+	}
+
+	mentions, err := s.adminRepo.ResolveDishCandidates(req.ResID, key, res, newAudit(adminID, auditUpdate, targetDishCandidate, req))
+	if err != nil {
+		return nil, mapAdminError(err, targetDishCandidate)
+	}
+	resp := &dtos.AdminResolveDishCandidateResponse{Status: res.Status, Mentions: len(mentions)}
+	if res.Status == entities.CandidateDismissed {
+		return resp, nil
+	}
+	resp.DishID = res.DishID
+	if res.NewDish != nil {
+		resp.DishID = res.NewDish.DishID
+	}
+	if res.Alias != nil {
+		resp.AliasID = res.Alias.DAID
+	}
+
+	// Scores are recomputed once for the whole batch rather than after every review
+	norm := normalize.NewService(s.recommendRepo)
+	for _, m := range mentions {
+		if err := norm.RunWithoutRecompute(ctx, m.SourceType, m.SourceID, m.HintDishID, m.ResID); err != nil {
+			log.Printf("[admin] normalizing review %d again after resolving %q failed: %v", m.SourceID, name, err)
+			resp.Failed++
+			continue
+		}
+		resp.Renormalized++
+	}
+	if resp.Renormalized > 0 {
+		if err := s.recommendRepo.RecomputeScoresAndRestaurants(); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Review extractions
+
+// SetCurrentReviewExtract rolls a user review back (or forward) to one of its stored extraction
+// runs. In one transaction the review's keywords are detached, normalized again from that run
+// and dish scores recomputed; when any step fails nothing changes.
+func (s *adminService) SetCurrentReviewExtract(ctx context.Context, adminID uint, req dtos.AdminSetCurrentReviewExtractRequest) (*dtos.AdminSetCurrentReviewExtractResponse, error) {
+	if req.ReviewID == 0 || req.ExtractID == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "review_id and extract_id are required")
+	}
+	review, err := s.recommendRepo.GetUserReviewByID(req.ReviewID)
+	if err != nil {
+		return nil, mapAdminError(err, "review")
+	}
+	renormalize := func(repo repository.RecommendRepository) error {
+		return normalize.NewService(repo).Run(ctx, "user", review.UserRevID, review.DishID, review.ResID)
+	}
+	if err := s.adminRepo.SetCurrentReviewExtract(req.ReviewID, "user", req.ExtractID, renormalize, newAudit(adminID, auditUpdate, targetReviewExtract, req)); err != nil {
+		return nil, mapAdminError(err, "extraction")
+	}
+	rec, err := s.recommendRepo.GetReviewExtract(req.ReviewID, "user")
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.AdminSetCurrentReviewExtractResponse{ReviewID: req.ReviewID, ExtractID: rec.ExtractID, KeywordsAttached: rec.KeywordsAttached}, nil
+}
+
+func (s *adminService) GetKeywordAliases(keywordID uint) ([]entities.KeywordAlias, error) {
+	return s.adminRepo.GetKeywordAliases(keywordID)
+}
+
+func (s *adminService) AddKeywordAlias(adminID uint, req dtos.AdminKeywordAliasRequest) (*entities.KeywordAlias, error) {
+	altWord := normalize.CleanText(req.AltWord)
+	if req.KeywordID == 0 || altWord == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "keyword_id and alt_word are required")
+	}
+	if _, err := s.adminRepo.GetKeywordByID(req.KeywordID); err != nil {
+		return nil, mapAdminError(err, targetKeyword)
+	}
+	alias := &entities.KeywordAlias{KeywordID: req.KeywordID, AltWord: altWord}
+	if err := s.adminRepo.CreateKeywordAlias(alias, newAudit(adminID, auditCreate, targetKeywordAlias, req)); err != nil {
+		return nil, mapAdminError(err, targetKeywordAlias)
+	}
+	return alias, nil
+}
+
+func (s *adminService) DeleteKeywordAlias(adminID uint, kaID uint) error {
+	return mapAdminError(s.adminRepo.DeleteKeywordAlias(kaID, newAudit(adminID, auditDelete, targetKeywordAlias, nil)), targetKeywordAlias)
+}
+
+// Whitelist
+
+func (s *adminService) GetWhitelist() ([]entities.RestaurantWhitelistEntry, error) {
+	return s.adminRepo.GetWhitelist()
+}
+
+func (s *adminService) AddToWhitelist(adminID uint, req dtos.AdminWhitelistRequest) (*entities.RestaurantWhitelistEntry, error) {
+	name := strings.TrimSpace(req.ResName)
+	if name == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "res_name is required")
+	}
+	entry := &entities.RestaurantWhitelistEntry{ResName: name}
+	if err := s.adminRepo.AddToWhitelist(entry, newAudit(adminID, auditCreate, targetWhitelist, req)); err != nil {
+		return nil, mapAdminError(err, "whitelist entry")
+	}
+	return entry, nil
+}
+
+func (s *adminService) RemoveFromWhitelist(adminID uint, wlID uint) error {
+	return mapAdminError(s.adminRepo.RemoveFromWhitelist(wlID, newAudit(adminID, auditDelete, targetWhitelist, nil)), "whitelist entry")
+}
+
+// Users and audit
+
+func (s *adminService) SetUserRole(adminID uint, userID uint, req dtos.AdminSetUserRoleRequest) error {
+	role := strings.ToLower(strings.TrimSpace(req.Role))
+	if role != entities.RoleUser && role != entities.RoleAdmin {
+		return fiber.NewError(fiber.StatusBadRequest, "role must be user or admin")
+	}
+	// Keeps at least the acting admin in place; another admin has to demote them
+	if userID == adminID {
+		return fiber.NewError(fiber.StatusBadRequest, "admins cannot change their own role")
+	}
+	return mapAdminError(s.adminRepo.SetUserRole(userID, role, newAudit(adminID, auditUpdate, targetUserRole, req)), "user")
+}
+
+func (s *adminService) GetAuditLogs(limit int, offset int) ([]entities.AdminAuditLog, error) {
+	if limit <= 0 || limit > maxAuditLogPage {
+		limit = maxAuditLogPage
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.adminRepo.GetAuditLogs(limit, offset)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/bestchayapol/DishDive/internal/dtos"
 	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/normalize"
 	"github.com/bestchayapol/DishDive/internal/repository"
 )
 
@@ -371,25 +372,22 @@ func (s *foodService) GetDishDetail(dishID uint, userID uint) (dtos.DishDetailRe
 	keywords, err := s.foodRepo.GetTopKeywordsByDishWithFrequency(dishID)
 	topKeywords := make(map[string][]string)
 	if err == nil {
-		flavorKeywords := []string{}
-		costKeywords := []string{}
-		generalKeywords := []string{}
+		// One list per configured category (older names such as "taste" count as their
+		// category); anything else is general
+		categories := normalize.CurrentCategories()
+		for _, name := range categories.Names() {
+			topKeywords[name] = []string{}
+		}
+		topKeywords["general"] = []string{}
 
 		for _, kw := range keywords {
 			keywordWithCount := kw.Keyword + " (" + fmt.Sprintf("%d", kw.Frequency) + ")"
-			switch strings.ToLower(kw.Category) {
-			case "flavor", "taste":
-				flavorKeywords = append(flavorKeywords, keywordWithCount)
-			case "cost", "price":
-				costKeywords = append(costKeywords, keywordWithCount)
-			default:
-				generalKeywords = append(generalKeywords, keywordWithCount)
+			category, ok := categories.Canonical(kw.Category)
+			if !ok {
+				category = "general"
 			}
+			topKeywords[category] = append(topKeywords[category], keywordWithCount)
 		}
-
-		topKeywords["flavor"] = flavorKeywords
-		topKeywords["cost"] = costKeywords
-		topKeywords["general"] = generalKeywords
 	}
 
 	// Check if favorite
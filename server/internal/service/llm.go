@@ -0,0 +1,13 @@
+package service
+
+import "github.com/bestchayapol/DishDive/internal/dtos"
+
+// LLMService reports LLM cache, usage and cost figures for admins and /metrics
+type LLMService interface {
+	GetCacheStats() dtos.LLMCacheStatsResponse
+	// GetLiveUsage returns budget state and in-process totals without touching the database
+	GetLiveUsage() dtos.LLMUsageResponse
+	// GetUsage returns in-process totals plus stored daily aggregates for the last days days
+	GetUsage(days int) (*dtos.LLMUsageResponse, error)
+	GetReviewUsage(reviewID uint) (*dtos.ReviewLLMUsageResponse, error)
+}
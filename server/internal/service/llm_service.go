@@ -0,0 +1,128 @@
+package service
+
+import (
+	"time"
+
+	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/llm"
+	"github.com/bestchayapol/DishDive/internal/repository"
+)
+
+const (
+	defaultUsageDays = 7
+	maxUsageDays     = 90
+)
+
+type llmService struct {
+	usageRepo repository.LLMUsageRepository
+	meter     *llm.Meter
+	cache     *llm.Cache
+}
+
+// NewLLMService builds the reporting service; meter and cache may be nil when disabled
+func NewLLMService(usageRepo repository.LLMUsageRepository, meter *llm.Meter, cache *llm.Cache) LLMService {
+	return &llmService{usageRepo: usageRepo, meter: meter, cache: cache}
+}
+
+func (s *llmService) GetCacheStats() dtos.LLMCacheStatsResponse {
+	st := s.cache.Stats()
+	resp := dtos.LLMCacheStatsResponse{
+		MemoryHits:  st.MemoryHits,
+		StoreHits:   st.StoreHits,
+		Misses:      st.Misses,
+		Writes:      st.Writes,
+		StoreErrors: st.StoreErrors,
+		Entries:     st.Entries,
+		Capacity:    st.Capacity,
+		Enabled:     s.cache != nil,
+	}
+	if lookups := st.MemoryHits + st.StoreHits + st.Misses; lookups > 0 {
+		resp.HitRatio = float64(st.MemoryHits+st.StoreHits) / float64(lookups)
+	}
+	return resp
+}
+
+func (s *llmService) GetUsage(days int) (*dtos.LLMUsageResponse, error) {
+	if days <= 0 {
+		days = defaultUsageDays
+	}
+	if days > maxUsageDays {
+		days = maxUsageDays
+	}
+	y, m, d := time.Now().Date()
+	since := time.Date(y, m, d-days+1, 0, 0, 0, 0, time.Local)
+	rows, err := s.usageRepo.GetLLMUsageDaily(since)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := s.GetLiveUsage()
+	resp.Daily = make([]dtos.LLMUsageDay, 0, len(rows))
+	for _, r := range rows {
+		resp.Daily = append(resp.Daily, dtos.LLMUsageDay{
+			Day:              r.Day.In(time.Local).Format("2006-01-02"),
+			Model:            r.Model,
+			Calls:            r.Calls,
+			Errors:           r.Errors,
+			SchemaCalls:      r.SchemaCalls,
+			Fallbacks:        r.Fallbacks,
+			PromptTokens:     r.PromptTokens,
+			CompletionTokens: r.CompletionTokens,
+			CostUSD:          r.CostUSD,
+			AvgLatencyMS:     r.AvgLatencyMS,
+		})
+	}
+	return &resp, nil
+}
+
+func (s *llmService) GetLiveUsage() dtos.LLMUsageResponse {
+	snap := s.meter.Snapshot()
+	resp := dtos.LLMUsageResponse{
+		SpentTodayUSD:  snap.SpentTodayUSD,
+		DailyBudgetUSD: snap.DailyBudgetUSD,
+		QueuePaused:    snap.Paused,
+		SinceStart:     []dtos.LLMModelTotals{},
+	}
+	for _, t := range snap.Models {
+		mt := dtos.LLMModelTotals{
+			Model:            t.Model,
+			Calls:            t.Calls,
+			Errors:           t.Errors,
+			SchemaCalls:      t.SchemaCalls,
+			Fallbacks:        t.Fallbacks,
+			PromptTokens:     t.PromptTokens,
+			CompletionTokens: t.CompletionTokens,
+			CostUSD:          t.CostUSD,
+		}
+		if t.Calls > 0 {
+			mt.AvgLatencyMS = t.LatencySeconds * 1000 / float64(t.Calls)
+		}
+		resp.SinceStart = append(resp.SinceStart, mt)
+	}
+	return resp
+}
+
+func (s *llmService) GetReviewUsage(reviewID uint) (*dtos.ReviewLLMUsageResponse, error) {
+	calls, err := s.usageRepo.GetLLMCallsBySource(reviewID, "user")
+	if err != nil {
+		return nil, err
+	}
+	resp := &dtos.ReviewLLMUsageResponse{ReviewID: reviewID, Calls: make([]dtos.LLMCallResponse, 0, len(calls))}
+	for _, c := range calls {
+		resp.Calls = append(resp.Calls, dtos.LLMCallResponse{
+			Model:            c.Model,
+			Outcome:          c.Outcome,
+			ResponseFormat:   c.ResponseFormat,
+			UsedFallback:     c.UsedFallback,
+			PromptTokens:     c.PromptTokens,
+			CompletionTokens: c.CompletionTokens,
+			LatencyMS:        c.LatencyMS,
+			CostUSD:          c.CostUSD,
+			CreatedAt:        c.CreatedAt,
+		})
+		resp.PromptTokens += c.PromptTokens
+		resp.CompletionTokens += c.CompletionTokens
+		resp.CostUSD += c.CostUSD
+	}
+	return resp, nil
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"context"
+
 	"github.com/bestchayapol/DishDive/internal/dtos"
+	"github.com/bestchayapol/DishDive/internal/entities"
 )
 
 type RecommendService interface {
@@ -16,7 +19,16 @@ type RecommendService interface {
 	GetRecommendedDishes(userID uint, resID *uint) ([]dtos.RestaurantMenuItemResponse, error)
 	// Filtered recommendations for a specific restaurant by name substring
 	GetRecommendedDishesFiltered(userID uint, resID uint, nameQuery string) ([]dtos.RestaurantMenuItemResponse, error)
+	// GetReviewOwner returns the user who wrote a review; 404 when it does not exist
+	GetReviewOwner(reviewID uint) (uint, error)
 	HasReviewExtract(sourceID uint, sourceType string) (bool, error)
 	HasNormalizedReview(sourceID uint) (bool, error)
+	GetReviewProcessingStatus(reviewID uint) (dtos.ReviewProcessingStatusResponse, error)
 	GetLatestReviewExtract(sourceID uint, sourceType string) (string, error)
+	GetReviewExtractHistory(reviewID uint) ([]dtos.ReviewExtractSummary, error)
+	// DiffReviewExtracts compares two runs of a review; zero IDs mean the previous and the current run
+	DiffReviewExtracts(reviewID uint, fromID uint, toID uint) (dtos.ReviewExtractDiffResponse, error)
+
+	// Background processing (handler for the review job queue)
+	ProcessReviewJob(ctx context.Context, job entities.ReviewJob) error
 }
@@ -3,31 +3,44 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/bestchayapol/DishDive/internal/dtos"
 	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/events"
 	"github.com/bestchayapol/DishDive/internal/extract"
 	"github.com/bestchayapol/DishDive/internal/llm"
 	"github.com/bestchayapol/DishDive/internal/normalize"
 	"github.com/bestchayapol/DishDive/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
+// ReviewQueue wakes the background workers that process submitted reviews
+type ReviewQueue interface {
+	Notify()
+}
+
 type recommendService struct {
 	foodRepo      repository.FoodRepository
 	recommendRepo repository.RecommendRepository
+	reviewJobRepo repository.ReviewJobRepository
+	reviewQueue   ReviewQueue
+	bus           *events.Bus
+	extractor     llm.Extractor
 	// mapping caches
 	flavorENToIDs map[string]map[uint]struct{}
 	costENToIDs   map[string]map[uint]struct{}
 }
 
-func NewRecommendService(foodRepo repository.FoodRepository, recommendRepo repository.RecommendRepository) RecommendService {
-	rs := &recommendService{foodRepo: foodRepo, recommendRepo: recommendRepo}
+func NewRecommendService(foodRepo repository.FoodRepository, recommendRepo repository.RecommendRepository, reviewJobRepo repository.ReviewJobRepository, reviewQueue ReviewQueue, bus *events.Bus, extractor llm.Extractor) RecommendService {
+	rs := &recommendService{foodRepo: foodRepo, recommendRepo: recommendRepo, reviewJobRepo: reviewJobRepo, reviewQueue: reviewQueue, bus: bus, extractor: extractor}
 	rs.loadKeywordMapping()
 	return rs
 }
@@ -210,6 +223,10 @@ func (s *recommendService) GetUserSettings(userID uint) (dtos.UserSettingsRespon
 			if groups, ok := revCost[row.KeywordID]; ok {
 				for _, en := range groups { if allowedCostGroups[en] { include = true; break } }
 			}
+		default:
+			// Other configured aspects (texture, service, ...) are listed as they are
+			name, ok := normalize.CurrentCategories().Canonical(cat)
+			include = ok && name == cat
 		}
 		if !include { continue }
 
@@ -325,55 +342,100 @@ func (s *recommendService) SubmitReview(req dtos.SubmitReviewRequest) (dtos.Subm
 	}
 
 	// 1.5) Minimal normalization in Go: ensure a ReviewDish link exists for this user review
-	if _, err := s.recommendRepo.EnsureReviewDish(reviewID, req.DishID, req.ResID); err != nil {
+	if _, err := s.recommendRepo.EnsureReviewDish(reviewID, "user", req.DishID, req.ResID); err != nil {
 		// Non-fatal; continue with extraction even if normalization link creation fails
 		fmt.Printf("[normalize] ensure review_dishes failed for review_id=%d: %v\n", reviewID, err)
 	}
 
-	// 2) Fire-and-forget: Go-native LLM extraction and upsert
+	// 2) The review_jobs row was stored with the review; wake a worker to process it now
+	if s.reviewQueue != nil {
+		s.reviewQueue.Notify()
+	}
+
+	return dtos.SubmitReviewResponse{Success: true, ReviewID: &reviewID}, nil
+}
+
+// ProcessReviewJob runs LLM extraction and normalization for one queued user review.
+// Both steps can be repeated safely, so a failed attempt is simply retried by the queue.
+func (s *recommendService) ProcessReviewJob(ctx context.Context, job entities.ReviewJob) error {
+	review, err := s.recommendRepo.GetUserReviewByID(job.SourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Review was deleted (e.g. account deletion) after the job was claimed; nothing to do
+			return nil
+		}
+		return fmt.Errorf("load review: %w", err)
+	}
+
+	s.publishReviewEvent(job, events.ReviewExtractionStarted, dtos.ReviewEventData{})
+
 	// We pass the restaurant name by looking up from Dish->Restaurant for better context
 	var restaurantName string
 	var dishName string
 	var knownCuisine *string
 	var knownRestrict *string
-	if dish, derr := s.foodRepo.GetDishByID(req.DishID); derr == nil {
+	var scoreBefore *entities.Dish
+	if dish, derr := s.foodRepo.GetDishByID(job.DishID); derr == nil {
+		scoreBefore = dish
 		dishName = dish.DishName
-		if dish.Cuisine != nil && *dish.Cuisine != "" { knownCuisine = dish.Cuisine }
-		if dish.Restriction != nil && *dish.Restriction != "" { knownRestrict = dish.Restriction }
+		if dish.Cuisine != nil && *dish.Cuisine != "" {
+			knownCuisine = dish.Cuisine
+		}
+		if dish.Restriction != nil && *dish.Restriction != "" {
+			knownRestrict = dish.Restriction
+		}
 		if res, rerr := s.foodRepo.GetRestaurantByID(dish.ResID); rerr == nil {
 			restaurantName = res.ResName
 		}
 	}
-	if restaurantName == "" {
-		restaurantName = ""
-	}
-	// Start Go-native extraction asynchronously
-	go func(reviewID uint, restaurantName, reviewText string, dishID uint, resID uint) {
-		time.Sleep(10 * time.Millisecond)
-		c := llm.NewClientFromEnv()
-		ex := extract.NewService(c, s.recommendRepo)
-		ctx := context.Background()
-		if err := ex.Run(ctx, extract.SingleReviewInput{
-			ReviewID:       reviewID,
-			RestaurantName: restaurantName,
-			ReviewText:     reviewText,
-			SourceType:     "user",
-			HintDish:       dishName,
-			KnownCuisine:   knownCuisine,
-			KnownRestrict:  knownRestrict,
-		}); err != nil {
-			fmt.Printf("[llm] go extractor error for review_id=%d: %v\n", reviewID, err)
-		} else {
-			fmt.Printf("[llm] go extractor completed for review_id=%d\n", reviewID)
-		}
-		// Normalize immediately in Go (mirrors previous Python auto-normalize)
-		norm := normalize.NewService(s.recommendRepo)
-		if err := norm.Run(ctx, "user", reviewID, dishID, resID); err != nil {
-			fmt.Printf("[normalize] go normalizer error for review_id=%d: %v\n", reviewID, err)
-		}
-	}(reviewID, restaurantName, req.ReviewText, req.DishID, req.ResID)
 
-	return dtos.SubmitReviewResponse{Success: true, ReviewID: &reviewID}, nil
+	ex := extract.NewService(s.extractor, s.recommendRepo)
+	if err := ex.Run(ctx, extract.SingleReviewInput{
+		ReviewID:       job.SourceID,
+		RestaurantName: restaurantName,
+		ReviewText:     review.UserRev,
+		SourceType:     job.SourceType,
+		HintDish:       dishName,
+		KnownCuisine:   knownCuisine,
+		KnownRestrict:  knownRestrict,
+	}); err != nil {
+		return fmt.Errorf("extract: %w", err)
+	}
+	fmt.Printf("[llm] go extractor completed for review_id=%d\n", job.SourceID)
+	if ext, err := s.recommendRepo.GetReviewExtract(job.SourceID, job.SourceType); err == nil {
+		s.publishReviewEvent(job, events.ReviewExtracted, dtos.ReviewEventData{Model: ext.Model, UsedFallback: ext.UsedFallback})
+	}
+
+	norm := normalize.NewService(s.recommendRepo)
+	if err := norm.Run(ctx, job.SourceType, job.SourceID, job.DishID, job.ResID); err != nil {
+		return fmt.Errorf("normalize: %w", err)
+	}
+	if ext, err := s.recommendRepo.GetReviewExtract(job.SourceID, job.SourceType); err == nil {
+		s.publishReviewEvent(job, events.ReviewNormalized, dtos.ReviewEventData{KeywordsAttached: ext.KeywordsAttached})
+	}
+	if after, err := s.foodRepo.GetDishByID(job.DishID); err == nil && scoreBefore != nil &&
+		(after.PositiveScore != scoreBefore.PositiveScore || after.NegativeScore != scoreBefore.NegativeScore || after.TotalScore != scoreBefore.TotalScore) {
+		s.bus.Publish(events.Event{
+			UserID: job.UserID,
+			Type:   events.DishScoreChanged,
+			Data: dtos.DishScoreEventData{
+				DishID:        after.DishID,
+				ReviewID:      job.SourceID,
+				PositiveScore: after.PositiveScore,
+				NegativeScore: after.NegativeScore,
+				TotalScore:    after.TotalScore,
+			},
+		})
+	}
+	return nil
+}
+
+// publishReviewEvent sends a review.* event for the job to its reviewer
+func (s *recommendService) publishReviewEvent(job entities.ReviewJob, eventType string, data dtos.ReviewEventData) {
+	data.ReviewID = job.SourceID
+	data.DishID = job.DishID
+	data.Attempt = job.Attempts
+	s.bus.Publish(events.Event{UserID: job.UserID, Type: eventType, Data: data})
 }
 
 func (s *recommendService) GetRecommendedDishes(userID uint, resID *uint) ([]dtos.RestaurantMenuItemResponse, error) {
@@ -439,16 +501,7 @@ func (s *recommendService) GetRecommendedDishes(userID uint, resID *uint) ([]dto
 
 	var scoredDishes []scoredDish
 	for _, dish := range dishes {
-		// Calculate sentiment percentage using proper review counts
-		positiveReviews, totalReviews, err := s.foodRepo.GetReviewCountsByDish(dish.DishID)
-		if err != nil {
-			positiveReviews, totalReviews = 0, 0
-		}
-
-		var sentimentPercentage float64 = 0
-		if totalReviews > 0 {
-			sentimentPercentage = float64(positiveReviews) / float64(totalReviews) * 100
-		}
+		sentimentPercentage := weightedSentiment(dish)
 
 		// Start with sentiment as base score
 		score := sentimentPercentage
@@ -618,16 +671,7 @@ func (s *recommendService) buildRecommendedMenuResponse(userID uint, dishes []en
 
 	var scoredDishes []scoredDish
 	for _, dish := range dishes {
-		// Calculate sentiment percentage using proper review counts
-		positiveReviews, totalReviews, err := s.foodRepo.GetReviewCountsByDish(dish.DishID)
-		if err != nil {
-			positiveReviews, totalReviews = 0, 0
-		}
-
-		var sentimentPercentage float64 = 0
-		if totalReviews > 0 {
-			sentimentPercentage = float64(positiveReviews) / float64(totalReviews) * 100
-		}
+		sentimentPercentage := weightedSentiment(dish)
 
 		// Start with sentiment as base score
 		score := sentimentPercentage
@@ -726,12 +770,95 @@ func (s *recommendService) buildRecommendedMenuResponse(userID uint, dishes []en
 	return resp, nil
 }
 
+func (s *recommendService) GetReviewOwner(reviewID uint) (uint, error) {
+	review, err := s.recommendRepo.GetUserReviewByID(reviewID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fiber.NewError(fiber.StatusNotFound, "review not found")
+		}
+		return 0, err
+	}
+	return review.UserID, nil
+}
+
+// weightedSentiment is the positive share of a dish's intensity-weighted reviews, in percent.
+// "Very good" reviews pull it up more than "okay" ones; the review counts are not affected.
+func weightedSentiment(d entities.Dish) float64 {
+	total := d.WeightedPositive + d.WeightedNegative
+	if total <= 0 {
+		return 0
+	}
+	return d.WeightedPositive / total * 100
+}
+
 func (s *recommendService) HasReviewExtract(sourceID uint, sourceType string) (bool, error) {
 	return s.recommendRepo.HasReviewExtract(sourceID, sourceType)
 }
 
 func (s *recommendService) HasNormalizedReview(sourceID uint) (bool, error) {
-	return s.recommendRepo.HasNormalizedReview(sourceID)
+	return s.recommendRepo.HasNormalizedReview(sourceID, "user")
+}
+
+// GetReviewProcessingStatus reports where a user review is in the job/extract/normalize pipeline
+func (s *recommendService) GetReviewProcessingStatus(reviewID uint) (dtos.ReviewProcessingStatusResponse, error) {
+	resp := dtos.ReviewProcessingStatusResponse{ReviewID: reviewID}
+
+	job, err := s.reviewJobRepo.GetReviewJobBySource(reviewID, "user")
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, err
+	}
+	ext, err := s.recommendRepo.GetReviewExtract(reviewID, "user")
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, err
+	}
+
+	if ext != nil {
+		resp.ExtractFound = true
+		resp.Normalized = ext.NormalizedAt != nil
+		resp.ExtractedAt = ext.ExtractedAt
+		resp.NormalizedAt = ext.NormalizedAt
+		resp.PromptVersion = ext.PromptVersion
+		resp.Model = ext.Model
+		resp.UsedFallback = ext.UsedFallback
+		resp.KeywordsAttached = ext.KeywordsAttached
+		resp.ValidationWarnings = rawJSON(ext.ValidationWarnings)
+		resp.Reasked = ext.Reasked
+	}
+	if job == nil && ext != nil && !resp.Normalized {
+		// Reviews processed before the job queue existed have no timestamps; fall back to row existence
+		if resp.Normalized, err = s.recommendRepo.HasNormalizedReview(reviewID, "user"); err != nil {
+			return resp, err
+		}
+	}
+
+	switch {
+	case job != nil && job.Status == entities.JobFailed:
+		resp.Status = "failed"
+	case job != nil && job.Status == entities.JobRunning:
+		resp.Status = "running"
+	case job != nil && job.Status == entities.JobPending && job.Attempts > 0:
+		resp.Status = "retrying"
+	case job != nil && job.Status == entities.JobPending:
+		resp.Status = "pending"
+	case resp.Normalized:
+		resp.Status = "normalized"
+	case resp.ExtractFound:
+		resp.Status = "extracted"
+	default:
+		resp.Status = "pending"
+	}
+
+	if job != nil {
+		resp.Attempts = job.Attempts
+		resp.LastError = job.LastError
+		resp.QueuedAt = &job.CreatedAt
+		resp.StartedAt = job.StartedAt
+		resp.FinishedAt = job.FinishedAt
+		if job.Status == entities.JobPending {
+			resp.NextRunAt = &job.NextRunAt
+		}
+	}
+	return resp, nil
 }
 
 // GetLatestReviewExtract returns the raw stored extraction JSON for diagnostics
@@ -752,3 +879,190 @@ func getCuisineImageLink(foodRepo repository.FoodRepository, cuisine *string) *s
 
 	return &imageURL
 }
+
+func (s *recommendService) GetReviewExtractHistory(reviewID uint) ([]dtos.ReviewExtractSummary, error) {
+	recs, err := s.recommendRepo.GetReviewExtractHistory(reviewID, "user")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dtos.ReviewExtractSummary, 0, len(recs))
+	for _, rec := range recs {
+		out = append(out, extractSummary(rec, parseExtractItems(rec.DataExtract)))
+	}
+	return out, nil
+}
+
+func (s *recommendService) DiffReviewExtracts(reviewID uint, fromID uint, toID uint) (dtos.ReviewExtractDiffResponse, error) {
+	resp := dtos.ReviewExtractDiffResponse{ReviewID: reviewID}
+	recs, err := s.recommendRepo.GetReviewExtractHistory(reviewID, "user")
+	if err != nil {
+		return resp, err
+	}
+	if len(recs) == 0 {
+		return resp, fiber.NewError(fiber.StatusNotFound, "review has no extractions")
+	}
+
+	find := func(id uint) *entities.ReviewExtract {
+		for i := range recs {
+			if recs[i].ExtractID == id {
+				return &recs[i]
+			}
+		}
+		return nil
+	}
+	var from, to *entities.ReviewExtract
+	if toID == 0 {
+		for i := range recs {
+			if recs[i].IsCurrent {
+				to = &recs[i]
+			}
+		}
+		if to == nil {
+			to = &recs[len(recs)-1]
+		}
+	} else if to = find(toID); to == nil {
+		return resp, fiber.NewError(fiber.StatusNotFound, "extraction "+strconv.FormatUint(uint64(toID), 10)+" does not belong to this review")
+	}
+	if fromID == 0 {
+		// The run just before "to"
+		for i := range recs {
+			if recs[i].ExtractID < to.ExtractID {
+				from = &recs[i]
+			}
+		}
+		if from == nil {
+			return resp, fiber.NewError(fiber.StatusNotFound, "no earlier extraction to compare with")
+		}
+	} else if from = find(fromID); from == nil {
+		return resp, fiber.NewError(fiber.StatusNotFound, "extraction "+strconv.FormatUint(uint64(fromID), 10)+" does not belong to this review")
+	}
+
+	fromItems, toItems := parseExtractItems(from.DataExtract), parseExtractItems(to.DataExtract)
+	resp.From = extractSummary(*from, fromItems)
+	resp.To = extractSummary(*to, toItems)
+	diffExtractItems(&resp, mergeItemsByDish(fromItems), mergeItemsByDish(toItems))
+	return resp, nil
+}
+
+func extractSummary(rec entities.ReviewExtract, items []llm.ExtractItem) dtos.ReviewExtractSummary {
+	return dtos.ReviewExtractSummary{
+		ExtractID:        rec.ExtractID,
+		IsCurrent:        rec.IsCurrent,
+		PromptVersion:    rec.PromptVersion,
+		PromptHash:       rec.PromptHash,
+		Model:            rec.Model,
+		UsedFallback:     rec.UsedFallback,
+		ExtractedAt:      rec.ExtractedAt,
+		NormalizedAt:     rec.NormalizedAt,
+		KeywordsAttached: rec.KeywordsAttached,
+		Items:            len(items),
+
+		ValidationWarnings: rawJSON(rec.ValidationWarnings),
+		Reasked:            rec.Reasked,
+	}
+}
+
+// rawJSON passes stored JSON through to a response; empty means absent
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+// parseExtractItems decodes stored extraction JSON; unreadable data counts as no items
+func parseExtractItems(raw string) []llm.ExtractItem {
+	var items []llm.ExtractItem
+	_ = json.Unmarshal([]byte(strings.TrimSpace(raw)), &items)
+	return items
+}
+
+// dishExtract is every item of one dish within a run, merged
+type dishExtract struct {
+	name        string
+	cuisine     string
+	restriction string
+	positive    map[string]struct{}
+	negative    map[string]struct{}
+}
+
+// mergeItemsByDish groups items by case-insensitive dish name
+func mergeItemsByDish(items []llm.ExtractItem) map[string]*dishExtract {
+	out := make(map[string]*dishExtract)
+	for _, it := range items {
+		name := strings.TrimSpace(it.Dish)
+		key := strings.ToLower(name)
+		if key == "" {
+			continue
+		}
+		d := out[key]
+		if d == nil {
+			d = &dishExtract{name: name, positive: map[string]struct{}{}, negative: map[string]struct{}{}}
+			out[key] = d
+		}
+		if it.Cuisine != nil && d.cuisine == "" {
+			d.cuisine = *it.Cuisine
+		}
+		if it.Restriction != nil && d.restriction == "" {
+			d.restriction = *it.Restriction
+		}
+		for _, t := range it.Sentiment.Positive {
+			d.positive[strings.TrimSpace(t)] = struct{}{}
+		}
+		for _, t := range it.Sentiment.Negative {
+			d.negative[strings.TrimSpace(t)] = struct{}{}
+		}
+	}
+	return out
+}
+
+func diffExtractItems(resp *dtos.ReviewExtractDiffResponse, from, to map[string]*dishExtract) {
+	resp.AddedDishes, resp.RemovedDishes, resp.UnchangedDishes = []string{}, []string{}, []string{}
+	resp.ChangedDishes = []dtos.DishExtractDiff{}
+	for key, d := range to {
+		if _, ok := from[key]; !ok {
+			resp.AddedDishes = append(resp.AddedDishes, d.name)
+		}
+	}
+	for key, f := range from {
+		t, ok := to[key]
+		if !ok {
+			resp.RemovedDishes = append(resp.RemovedDishes, f.name)
+			continue
+		}
+		diff := dtos.DishExtractDiff{
+			Dish:            t.name,
+			PositiveAdded:   setMinus(t.positive, f.positive),
+			PositiveRemoved: setMinus(f.positive, t.positive),
+			NegativeAdded:   setMinus(t.negative, f.negative),
+			NegativeRemoved: setMinus(f.negative, t.negative),
+		}
+		if f.cuisine != t.cuisine {
+			diff.CuisineFrom, diff.CuisineTo = f.cuisine, t.cuisine
+		}
+		if f.restriction != t.restriction {
+			diff.RestrictionFrom, diff.RestrictionTo = f.restriction, t.restriction
+		}
+		if f.cuisine == t.cuisine && f.restriction == t.restriction && len(diff.PositiveAdded)+len(diff.PositiveRemoved)+len(diff.NegativeAdded)+len(diff.NegativeRemoved) == 0 {
+			resp.UnchangedDishes = append(resp.UnchangedDishes, t.name)
+			continue
+		}
+		resp.ChangedDishes = append(resp.ChangedDishes, diff)
+	}
+	sort.Strings(resp.AddedDishes)
+	sort.Strings(resp.RemovedDishes)
+	sort.Strings(resp.UnchangedDishes)
+	sort.Slice(resp.ChangedDishes, func(i, j int) bool { return resp.ChangedDishes[i].Dish < resp.ChangedDishes[j].Dish })
+}
+
+// setMinus returns the sorted members of a that are not in b
+func setMinus(a, b map[string]struct{}) []string {
+	var out []string
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
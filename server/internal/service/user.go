@@ -6,7 +6,7 @@ import (
 )
 
 type UserService interface {
-	GetUsers() ([]entities.User, error)
+	GetUsers() ([]dtos.AdminUserView, error)
 	GetUserByUserId(int) (*entities.User, error)
 	GetUserByToken(int) (*entities.User, error)
 
@@ -19,5 +19,26 @@ type UserService interface {
 	PatchEditUserProfileByUserId(int, dtos.EditUserProfileByUserIdRequest) (*entities.User, error)
 
 	Register(request dtos.RegisterRequest) (*dtos.UserResponse, error)
-	Login(request dtos.LoginRequest, jwtSecret string) (*dtos.LoginResponse, error)
+	Login(request dtos.LoginRequest, clientIP string) (*dtos.LoginResponse, error)
+
+	////////////////////////////////////////////////////////////////////
+	// Sessions
+
+	RefreshToken(refreshToken string) (*dtos.LoginResponse, error)
+	Logout(userID uint, sessionID string, refreshToken string) error
+	RevokeAllSessions(userID uint) error
+	IsSessionActive(userID uint, sessionID string) (bool, error)
+
+	////////////////////////////////////////////////////////////////////
+	// Roles
+
+	GetUserRole(userID uint) (string, error)
+
+	////////////////////////////////////////////////////////////////////
+	// Credentials and account lifecycle
+
+	ChangePassword(userID uint, sessionID string, request dtos.ChangePasswordRequest) error
+	RequestPasswordReset(request dtos.RequestPasswordResetRequest) error
+	ResetPassword(request dtos.ResetPasswordRequest) error
+	DeleteAccount(userID uint, request dtos.DeleteAccountRequest) error
 }
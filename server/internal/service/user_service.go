@@ -1,15 +1,23 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"log"
-	"strconv"
+	"time"
 
+	"github.com/bestchayapol/DishDive/internal/auth"
 	"github.com/bestchayapol/DishDive/internal/dtos"
 	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/notify"
+	"github.com/bestchayapol/DishDive/internal/ratelimit"
 	"github.com/bestchayapol/DishDive/internal/repository"
+	"github.com/bestchayapol/DishDive/internal/validate"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -17,24 +25,64 @@ import (
 type userService struct {
 	userRepo      repository.UserRepository
 	recommendRepo repository.RecommendRepository
-	jwtSecret     string
+	tokens        auth.TokenService
+	refreshTTL    time.Duration
+	notifier      notify.Notifier
+	lockout       LoginLockout
 }
 
-func NewUserService(userRepo repository.UserRepository, recommendRepo repository.RecommendRepository, jwtSecret string) userService {
+// LoginLockout locks an account for Duration after MaxFailures consecutive wrong passwords
+// from one client IP; other IPs can still log in. MaxFailures <= 0 disables the lockout.
+type LoginLockout struct {
+	MaxFailures int
+	Duration    time.Duration
+}
+
+const (
+	// Refresh-token lifetime used when the caller passes a non-positive TTL
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	passwordResetTTL       = 30 * time.Minute
+)
+
+func NewUserService(userRepo repository.UserRepository, recommendRepo repository.RecommendRepository, tokens auth.TokenService, refreshTTL time.Duration, notifier notify.Notifier, lockout LoginLockout) userService {
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+	if notifier == nil {
+		notifier = notify.NewLogNotifier()
+	}
 	return userService{
 		userRepo:      userRepo,
 		recommendRepo: recommendRepo,
-		jwtSecret:     jwtSecret,
+		tokens:        tokens,
+		refreshTTL:    refreshTTL,
+		notifier:      notifier,
+		lockout:       lockout,
 	}
 }
 
-func (s userService) GetUsers() ([]entities.User, error) {
+func (s userService) GetUsers() ([]dtos.AdminUserView, error) {
 	users, err := s.userRepo.GetAllUser()
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
-	return users, nil
+	sessions, err := s.userRepo.CountActiveSessions()
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	views := make([]dtos.AdminUserView, 0, len(users))
+	for _, user := range users {
+		views = append(views, dtos.AdminUserView{
+			UserID:         user.UserID,
+			Username:       user.Username,
+			ImageLink:      user.ImageLink,
+			Role:           user.Role,
+			ActiveSessions: sessions[user.UserID],
+		})
+	}
+	return views, nil
 }
 
 func (s userService) GetUserByUserId(userid int) (*entities.User, error) {
@@ -97,6 +145,12 @@ func (s userService) GetEditUserProfileByUserId(userid int) (*entities.User, err
 }
 
 func (s userService) PatchEditUserProfileByUserId(userid int, req dtos.EditUserProfileByUserIdRequest) (*entities.User, error) {
+	if req.Username != nil {
+		if err := s.ensureUsernameAvailable(*req.Username, uint(userid)); err != nil {
+			return nil, err
+		}
+	}
+
 	user := &entities.User{
 		UserID:    uint(userid),
 		Username:  req.Username,
@@ -105,6 +159,9 @@ func (s userService) PatchEditUserProfileByUserId(userid int, req dtos.EditUserP
 
 	err := s.userRepo.PatchEditUserProfileByUserId(user)
 	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errUsernameTaken()
+		}
 		log.Println(err)
 		return nil, err
 	}
@@ -120,6 +177,13 @@ func (s userService) PatchEditUserProfileByUserId(userid int, req dtos.EditUserP
 }
 
 func (s userService) Register(request dtos.RegisterRequest) (*dtos.UserResponse, error) {
+	if err := validate.RegisterRequest(request); err != nil {
+		return nil, err
+	}
+	if err := s.ensureUsernameAvailable(*request.Username, 0); err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.PasswordHash), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -129,10 +193,15 @@ func (s userService) Register(request dtos.RegisterRequest) (*dtos.UserResponse,
 		Username:     request.Username,
 		ImageLink:    request.ImageLink,
 		PasswordHash: string(hashedPassword),
+		Role:         entities.RoleUser,
 	}
 
 	err = s.userRepo.CreateUser(&user)
 	if err != nil {
+		// Lost a race with a concurrent registration; the unique index has the final say
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errUsernameTaken()
+		}
 		return nil, err
 	}
 
@@ -143,7 +212,7 @@ func (s userService) Register(request dtos.RegisterRequest) (*dtos.UserResponse,
 	}, nil
 }
 
-func (s userService) Login(request dtos.LoginRequest, jwtSecret string) (*dtos.LoginResponse, error) {
+func (s userService) Login(request dtos.LoginRequest, clientIP string) (*dtos.LoginResponse, error) {
 	username := *request.Username
 	user, err := s.userRepo.GetUserByUsername(username)
 	if err != nil {
@@ -153,10 +222,35 @@ func (s userService) Login(request dtos.LoginRequest, jwtSecret string) (*dtos.L
 		return nil, err
 	}
 
+	// Refuse locked accounts before spending a bcrypt compare on them
+	if s.lockout.MaxFailures > 0 {
+		lockedUntil, err := s.userRepo.GetLoginLock(user.UserID, clientIP)
+		if err != nil {
+			return nil, err
+		}
+		if wait := lockedFor(lockedUntil); wait > 0 {
+			return nil, errAccountLocked(wait)
+		}
+	}
+
 	// Compare password hash
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(request.PasswordHash)); err != nil {
+		if s.lockout.MaxFailures > 0 {
+			lockedUntil, lerr := s.userRepo.RecordFailedLogin(user.UserID, clientIP, s.lockout.MaxFailures, time.Now().Add(s.lockout.Duration))
+			if lerr != nil {
+				log.Printf("[auth] could not record failed login for user %d from %s: %v", user.UserID, clientIP, lerr)
+			} else if wait := lockedFor(lockedUntil); wait > 0 {
+				log.Printf("[auth] user %d locked out from %s for %s after %d failed logins", user.UserID, clientIP, wait.Round(time.Second), s.lockout.MaxFailures)
+				return nil, errAccountLocked(wait)
+			}
+		}
 		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid credentials")
 	}
+	if s.lockout.MaxFailures > 0 {
+		if err := s.userRepo.ResetFailedLogins(user.UserID, clientIP); err != nil {
+			log.Printf("[auth] could not reset failed logins for user %d from %s: %v", user.UserID, clientIP, err)
+		}
+	}
 
 	// Initialize user preferences if they don't exist (happens on every login)
 	err = s.ensureUserPreferencesExist(user.UserID)
@@ -165,24 +259,307 @@ func (s userService) Login(request dtos.LoginRequest, jwtSecret string) (*dtos.L
 		// Don't fail login if preference initialization fails
 	}
 
-	// Generate JWT token
-	claims := jwt.RegisteredClaims{
-		Issuer: strconv.Itoa(int(user.UserID)),
+	// Start a new session: refresh-token family + short-lived access token
+	familyID, err := newOpaqueToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueSession(user, familyID, nil)
+}
+
+// RefreshToken rotates a refresh token and issues a new access token for the same session.
+// Presenting an already-rotated token is treated as theft and revokes the whole session.
+func (s userService) RefreshToken(refreshToken string) (*dtos.LoginResponse, error) {
+	if refreshToken == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "refresh_token is required")
+	}
+	current, err := s.userRepo.GetRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "invalid refresh token")
+		}
+		return nil, err
+	}
+	if current.RevokedAt != nil {
+		log.Printf("[auth] refresh token reuse detected for user %d, revoking session %s", current.UserID, current.FamilyID)
+		if err := s.userRepo.RevokeRefreshTokenFamily(current.FamilyID); err != nil {
+			log.Println(err)
+		}
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "refresh token has been revoked")
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "refresh token has expired")
+	}
+
+	user, err := s.userRepo.GetUserByUserId(int(current.UserID))
+	if err != nil {
+		return nil, err
+	}
+	if user.UserID == 0 {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "invalid refresh token")
+	}
+	return s.issueSession(user, current.FamilyID, current)
+}
+
+// Logout revokes the current session; a refresh token in the body may name the session instead
+func (s userService) Logout(userID uint, sessionID string, refreshToken string) error {
+	if refreshToken != "" {
+		token, err := s.userRepo.GetRefreshTokenByHash(hashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid refresh token")
+			}
+			return err
+		}
+		if token.UserID != userID {
+			return fiber.NewError(fiber.StatusForbidden, "refresh token belongs to another user")
+		}
+		sessionID = token.FamilyID
+	}
+	if sessionID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "no session to log out")
+	}
+	return s.userRepo.RevokeRefreshTokenFamily(sessionID)
+}
+
+// RevokeAllSessions logs the user out everywhere; outstanding access tokens stop working immediately
+func (s userService) RevokeAllSessions(userID uint) error {
+	return s.userRepo.RevokeAllRefreshTokens(userID)
+}
+
+func (s userService) IsSessionActive(userID uint, sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	return s.userRepo.HasActiveSession(userID, sessionID)
+}
+
+// GetUserRole returns the stored role of a user, or "" when the user no longer exists
+func (s userService) GetUserRole(userID uint) (string, error) {
+	user, err := s.userRepo.GetUserByUserId(int(userID))
+	if err != nil {
+		return "", err
+	}
+	if user.UserID == 0 {
+		return "", nil
+	}
+	return user.Role, nil
+}
+
+// ChangePassword verifies the old password, stores the new one and logs out every other session
+func (s userService) ChangePassword(userID uint, sessionID string, request dtos.ChangePasswordRequest) error {
+	if request.OldPassword == "" || request.NewPassword == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "old_password and new_password are required")
+	}
+	user, err := s.userRepo.GetUserByUserId(int(userID))
+	if err != nil {
+		return err
+	}
+	if user.UserID == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "user data is not found")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(request.OldPassword)); err != nil {
+		return fiber.NewError(fiber.StatusForbidden, "old password is incorrect")
+	}
+	if err := s.setPassword(userID, request.NewPassword); err != nil {
+		return err
+	}
+	if err := s.userRepo.InvalidatePasswordResetTokens(userID); err != nil {
+		log.Println(err)
+	}
+	return s.userRepo.RevokeOtherRefreshTokens(userID, sessionID)
+}
+
+// RequestPasswordReset issues a single-use reset token through the notifier.
+// It succeeds for unknown usernames too so the endpoint cannot be used to probe accounts.
+func (s userService) RequestPasswordReset(request dtos.RequestPasswordResetRequest) error {
+	if request.Username == nil || *request.Username == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "user_name is required")
+	}
+	user, err := s.userRepo.GetUserByUsername(*request.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	raw, err := newOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	token := entities.PasswordResetToken{
+		UserID:    user.UserID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(passwordResetTTL),
+		CreatedAt: now,
+	}
+	if err := s.userRepo.CreatePasswordResetToken(&token); err != nil {
+		return err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	jwtToken, err := token.SignedString([]byte(jwtSecret))
+	to := notify.Recipient{UserID: user.UserID}
+	if user.Username != nil {
+		to.Username = *user.Username
+	}
+	return s.notifier.SendPasswordReset(context.Background(), to, raw, token.ExpiresAt)
+}
+
+// ResetPassword consumes a reset token, sets the new password and revokes all sessions
+func (s userService) ResetPassword(request dtos.ResetPasswordRequest) error {
+	if request.ResetToken == "" || request.NewPassword == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "reset_token and new_password are required")
+	}
+	if err := validate.NewPassword("new_password", request.NewPassword); err != nil {
+		return err
+	}
+	token, err := s.userRepo.ConsumePasswordResetToken(hashToken(request.ResetToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, repository.ErrResetTokenUnusable) {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid or expired reset token")
+		}
+		return err
+	}
+	if err := s.setPassword(token.UserID, request.NewPassword); err != nil {
+		return err
+	}
+	if err := s.userRepo.InvalidatePasswordResetTokens(token.UserID); err != nil {
+		log.Println(err)
+	}
+	return s.userRepo.RevokeAllRefreshTokens(token.UserID)
+}
+
+// DeleteAccount removes the user with their favorites, settings and reviews, then recomputes dish scores
+func (s userService) DeleteAccount(userID uint, request dtos.DeleteAccountRequest) error {
+	user, err := s.userRepo.GetUserByUserId(int(userID))
+	if err != nil {
+		return err
+	}
+	if user.UserID == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "user data is not found")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(request.Password)); err != nil {
+		return fiber.NewError(fiber.StatusForbidden, "password is incorrect")
+	}
+
+	affected, err := s.userRepo.DeleteUserCascade(userID)
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted user %d; recomputing scores for %d dishes", userID, len(affected))
+	if len(affected) > 0 {
+		if err := s.recommendRepo.RecomputeScoresAndRestaurants(); err != nil {
+			log.Printf("Warning: score recompute after deleting user %d failed: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+func (s userService) setPassword(userID uint, password string) error {
+	if err := validate.NewPassword("new_password", password); err != nil {
+		return err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.UpdatePasswordHash(userID, string(hashed))
+}
+
+// ensureUsernameAvailable returns a 409 validate.Error when another user already has the name
+func (s userService) ensureUsernameAvailable(username string, selfID uint) error {
+	existing, err := s.userRepo.GetUserByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.UserID != selfID {
+		return errUsernameTaken()
+	}
+	return nil
+}
+
+// lockedFor returns how long an account stays locked, or 0 when it is not locked
+func lockedFor(lockedUntil *time.Time) time.Duration {
+	if lockedUntil == nil {
+		return 0
+	}
+	if wait := time.Until(*lockedUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+func errAccountLocked(wait time.Duration) error {
+	return &ratelimit.Error{Reason: "account_locked", Message: "too many failed login attempts, try again later", RetryAfter: wait}
+}
+
+func errUsernameTaken() error {
+	return validate.Conflict("user_name", "taken", "username is already taken")
+}
+
+// issueSession signs an access token and stores a fresh refresh token in the given family.
+// When previous is set the new refresh token replaces it (rotation).
+func (s userService) issueSession(user *entities.User, familyID string, previous *entities.RefreshToken) (*dtos.LoginResponse, error) {
+	now := time.Now()
+	jwtToken, accessExpiresAt, err := s.tokens.Issue(user.UserID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefresh, err := newOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := now.Add(s.refreshTTL)
+	next := &entities.RefreshToken{
+		UserID:    user.UserID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(rawRefresh),
+		ExpiresAt: refreshExpiresAt,
+		CreatedAt: now,
+	}
+	if previous == nil {
+		err = s.userRepo.CreateRefreshToken(next)
+	} else {
+		err = s.userRepo.RotateRefreshToken(previous, next)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Lost a race with a concurrent refresh of the same token
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "refresh token has been revoked")
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return &dtos.LoginResponse{
-		UserID:   user.UserID,
-		Username: user.Username,
-		Token:    &jwtToken,
+		UserID:           user.UserID,
+		Username:         user.Username,
+		Token:            &jwtToken,
+		ExpiresAt:        &accessExpiresAt,
+		RefreshToken:     &rawRefresh,
+		RefreshExpiresAt: &refreshExpiresAt,
 	}, nil
 }
 
+// newOpaqueToken returns n random bytes encoded as URL-safe base64
+func newOpaqueToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is how refresh tokens are stored; the raw value is only ever held by the client
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
 // Ensure user has all required preference settings (called on every login)
 func (s userService) ensureUserPreferencesExist(userID uint) error {
 	// Check if user already has preference settings
@@ -201,8 +578,11 @@ func (s userService) ensureUserPreferencesExist(userID uint) error {
 	
 	var newSettings []entities.PreferenceBlacklist
 	
-	// 1. Get ALL keywords to filter from
-	allKeywords, err := s.recommendRepo.GetKeywordsByCategory([]string{"flavor", "cost", "system", "cuisine", "restriction"})
+	// 1. Get the keywords of the categories that are initialized. Other configured aspects
+	// (texture, service, ...) grow with every normalized review, so their rows are created only
+	// when the user sets one (BulkUpdateUserSettings upserts; reads default to 0).
+	categories := []string{"system", "cuisine", "restriction", "flavor", "cost"}
+	allKeywords, err := s.recommendRepo.GetKeywordsByCategory(categories)
 	if err != nil {
 		return err
 	}
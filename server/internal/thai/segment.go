@@ -0,0 +1,236 @@
+// Package thai splits Thai text, which is written without spaces between words, into words
+// by dictionary-based maximal matching.
+package thai
+
+import (
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// Token is one segment of the input. Start and End are rune offsets.
+type Token struct {
+	Text       string
+	Start, End int
+	Known      bool // found in the dictionary; unknown Thai runs and non-Thai words are false
+}
+
+type node struct {
+	next map[rune]*node
+	word bool
+}
+
+// Dictionary is an immutable word trie; build it with NewDictionary
+type Dictionary struct {
+	root  node
+	count int
+}
+
+// NewDictionary builds a dictionary from words. Blank entries and surrounding spaces are ignored.
+func NewDictionary(words ...[]string) *Dictionary {
+	d := &Dictionary{}
+	for _, list := range words {
+		for _, w := range list {
+			d.add(strings.TrimSpace(w))
+		}
+	}
+	return d
+}
+
+func (d *Dictionary) add(word string) {
+	if word == "" {
+		return
+	}
+	n := &d.root
+	for _, r := range word {
+		if n.next == nil {
+			n.next = map[rune]*node{}
+		}
+		child := n.next[r]
+		if child == nil {
+			child = &node{}
+			n.next[r] = child
+		}
+		n = child
+	}
+	if !n.word {
+		n.word = true
+		d.count++
+	}
+}
+
+// Len is the number of distinct words
+func (d *Dictionary) Len() int {
+	if d == nil {
+		return 0
+	}
+	return d.count
+}
+
+// Contains reports whether word is in the dictionary
+func (d *Dictionary) Contains(word string) bool {
+	if d == nil {
+		return false
+	}
+	n := &d.root
+	for _, r := range word {
+		if n = n.next[r]; n == nil {
+			return false
+		}
+	}
+	return n.word
+}
+
+// IsThai reports whether r is in the Thai block
+func IsThai(r rune) bool {
+	return unicode.Is(unicode.Thai, r)
+}
+
+// Segment splits text into tokens. Whitespace and punctuation separate tokens and are dropped.
+// Thai runs are split by maximal matching: the fewest unknown characters, then the fewest words.
+// Unknown characters are grouped into runs that never split a consonant from its vowels or marks.
+func (d *Dictionary) Segment(text string) []Token {
+	runes := []rune(text)
+	var tokens []Token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case IsThai(r) && !isThaiPunct(r):
+			j := i
+			for j < len(runes) && IsThai(runes[j]) && !isThaiPunct(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, d.segmentThai(runes, i, j)...)
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && !IsThai(runes[j]) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || unicode.IsMark(runes[j])) {
+				j++
+			}
+			tokens = append(tokens, Token{Text: string(runes[i:j]), Start: i, End: j})
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// Words returns the text of Segment's tokens
+func (d *Dictionary) Words(text string) []string {
+	tokens := d.Segment(text)
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.Text
+	}
+	return words
+}
+
+type cost struct {
+	unknown, words int
+}
+
+func (c cost) less(o cost) bool {
+	if c.unknown != o.unknown {
+		return c.unknown < o.unknown
+	}
+	return c.words < o.words
+}
+
+// segmentThai runs maximal matching over runes[from:to], which holds only Thai letters
+func (d *Dictionary) segmentThai(runes []rune, from, to int) []Token {
+	n := to - from
+	best := make([]cost, n+1)
+	step := make([]int, n+1) // end of the segment chosen at each position
+	known := make([]bool, n+1)
+	for i := n - 1; i >= 0; i-- {
+		// Unknown cluster first, so dictionary words win ties
+		j := nextBoundary(runes, from, to, from+i) - from
+		best[i] = cost{unknown: best[j].unknown + (j - i), words: best[j].words + 1}
+		step[i], known[i] = j, false
+
+		nd := &d.root
+		for k := i; k < n && nd != nil; k++ {
+			nd = nd.next[runes[from+k]]
+			if nd == nil || !nd.word || !isBoundary(runes, from, to, from+k+1) {
+				continue
+			}
+			c := cost{unknown: best[k+1].unknown, words: best[k+1].words + 1}
+			// On a tie the longer word wins; k only grows
+			if !best[i].less(c) {
+				best[i], step[i], known[i] = c, k+1, true
+			}
+		}
+	}
+
+	var tokens []Token
+	for i := 0; i < n; i = step[i] {
+		t := Token{Text: string(runes[from+i : from+step[i]]), Start: from + i, End: from + step[i], Known: known[i]}
+		// Merge consecutive unknown clusters into one token
+		if last := len(tokens) - 1; !t.Known && last >= 0 && !tokens[last].Known && tokens[last].End == t.Start {
+			tokens[last].Text += t.Text
+			tokens[last].End = t.End
+			continue
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// nextBoundary is the first position after i where a word may end
+func nextBoundary(runes []rune, from, to, i int) int {
+	j := i + 1
+	for j < to && !isBoundary(runes, from, to, j) {
+		j++
+	}
+	return j
+}
+
+// isBoundary reports whether a word may end before runes[j]: not before a following vowel,
+// tone mark or other combining sign, and not after a leading vowel
+func isBoundary(runes []rune, from, to, j int) bool {
+	if j <= from || j >= to {
+		return true
+	}
+	return !isFollowing(runes[j]) && !isLeadingVowel(runes[j-1])
+}
+
+// isFollowing reports whether r attaches to the preceding consonant
+func isFollowing(r rune) bool {
+	switch {
+	case r == 0x0E30, r == 0x0E32, r == 0x0E33, r == 0x0E45: // ะ า ำ ๅ
+		return true
+	case r == 0x0E31, r >= 0x0E34 && r <= 0x0E3A: // above and below vowels, phinthu
+		return true
+	case r >= 0x0E47 && r <= 0x0E4E: // maitaikhu, tone marks, thanthakhat, nikhahit, yamakkan
+		return true
+	}
+	return false
+}
+
+// isLeadingVowel reports whether r is written before the consonant it follows in speech (เ แ โ ใ ไ)
+func isLeadingVowel(r rune) bool {
+	return r >= 0x0E40 && r <= 0x0E44
+}
+
+// isThaiPunct reports Thai characters that separate words: paiyannoi, baht sign, mai yamok,
+// fongman and the end-of-text marks
+func isThaiPunct(r rune) bool {
+	return r == 0x0E2F || r == 0x0E3F || r == 0x0E46 || r == 0x0E4F || r == 0x0E5A || r == 0x0E5B
+}
+
+var current atomic.Pointer[Dictionary]
+
+func init() {
+	current.Store(NewDictionary())
+}
+
+// Use replaces the shared dictionary
+func Use(d *Dictionary) {
+	current.Store(d)
+}
+
+// Current returns the shared dictionary set by Use; it is empty until then
+func Current() *Dictionary {
+	return current.Load()
+}
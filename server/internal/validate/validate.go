@@ -0,0 +1,173 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bestchayapol/DishDive/internal/dtos"
+)
+
+// Username and password limits. bcrypt ignores input beyond 72 bytes, so longer passwords are rejected.
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 30
+	PasswordMinLength = 8
+	PasswordMaxBytes  = 72
+)
+
+// Error is a typed request error carrying field-level details.
+// Status is 422 for rule violations and 409 for conflicts such as a taken username.
+type Error struct {
+	Status  int
+	Message string
+	Fields  []dtos.FieldError
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	if len(parts) == 0 {
+		return e.Message
+	}
+	return e.Message + " (" + strings.Join(parts, "; ") + ")"
+}
+
+// Conflict builds a 409 error for a single field
+func Conflict(field string, code string, message string) *Error {
+	return &Error{
+		Status:  http.StatusConflict,
+		Message: message,
+		Fields:  []dtos.FieldError{{Field: field, Code: code, Message: message}},
+	}
+}
+
+type collector struct {
+	fields []dtos.FieldError
+}
+
+func (c *collector) add(fe *dtos.FieldError) {
+	if fe != nil {
+		c.fields = append(c.fields, *fe)
+	}
+}
+
+func (c *collector) err() error {
+	if len(c.fields) == 0 {
+		return nil
+	}
+	return &Error{Status: http.StatusUnprocessableEntity, Message: "validation failed", Fields: c.fields}
+}
+
+// RegisterRequest validates a registration request
+func RegisterRequest(req dtos.RegisterRequest) error {
+	var c collector
+	username := ""
+	if req.Username != nil {
+		username = *req.Username
+	}
+	c.add(Username("user_name", username))
+	c.add(Password("password_hash", req.PasswordHash, username))
+	return c.err()
+}
+
+// EditUserProfileRequest validates a profile patch; absent fields are left alone
+func EditUserProfileRequest(req dtos.EditUserProfileByUserIdRequest) error {
+	var c collector
+	if req.Username != nil {
+		c.add(Username("user_name", *req.Username))
+	}
+	return c.err()
+}
+
+// NewPassword validates a password chosen in change/reset flows
+func NewPassword(field string, password string) error {
+	var c collector
+	c.add(Password(field, password, ""))
+	return c.err()
+}
+
+// Username allows Thai script, ASCII letters and digits plus _ . - inside the name.
+// Leading/trailing punctuation and whitespace are rejected so names stay unambiguous.
+func Username(field string, username string) *dtos.FieldError {
+	if username == "" {
+		return &dtos.FieldError{Field: field, Code: "required", Message: "username is required"}
+	}
+	n := utf8.RuneCountInString(username)
+	if n < UsernameMinLength || n > UsernameMaxLength {
+		return &dtos.FieldError{Field: field, Code: "length",
+			Message: fmt.Sprintf("username must be %d-%d characters", UsernameMinLength, UsernameMaxLength)}
+	}
+	for _, r := range username {
+		if !isUsernameRune(r) {
+			return &dtos.FieldError{Field: field, Code: "charset",
+				Message: fmt.Sprintf("username contains a disallowed character %q; use Thai or English letters, digits, _ . -", r)}
+		}
+	}
+	first, _ := utf8.DecodeRuneInString(username)
+	last, _ := utf8.DecodeLastRuneInString(username)
+	if isUsernamePunct(first) || isUsernamePunct(last) {
+		return &dtos.FieldError{Field: field, Code: "charset", Message: "username cannot start or end with _ . -"}
+	}
+	if isThaiCombining(first) {
+		return &dtos.FieldError{Field: field, Code: "charset", Message: "username cannot start with a Thai vowel or tone mark"}
+	}
+	return nil
+}
+
+// Password requires 8-72 bytes with at least one letter and one digit, and must differ from the username
+func Password(field string, password string, username string) *dtos.FieldError {
+	if password == "" {
+		return &dtos.FieldError{Field: field, Code: "required", Message: "password is required"}
+	}
+	if utf8.RuneCountInString(password) < PasswordMinLength {
+		return &dtos.FieldError{Field: field, Code: "length",
+			Message: fmt.Sprintf("password must be at least %d characters", PasswordMinLength)}
+	}
+	if len(password) > PasswordMaxBytes {
+		return &dtos.FieldError{Field: field, Code: "length",
+			Message: fmt.Sprintf("password must be at most %d bytes", PasswordMaxBytes)}
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return &dtos.FieldError{Field: field, Code: "weak", Message: "password must contain at least one letter and one digit"}
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return &dtos.FieldError{Field: field, Code: "weak", Message: "password must not equal the username"}
+	}
+	return nil
+}
+
+func isUsernameRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case isUsernamePunct(r):
+		return true
+	case r >= 0x0E01 && r <= 0x0E3A, r >= 0x0E40 && r <= 0x0E4E, r >= 0x0E50 && r <= 0x0E59:
+		// Thai consonants, vowels, tone marks and Thai digits (excludes currency ฿ and punctuation ๏ ๚ ๛)
+		return true
+	}
+	return false
+}
+
+func isUsernamePunct(r rune) bool {
+	return r == '_' || r == '.' || r == '-'
+}
+
+// isThaiCombining reports above/below vowels and tone marks that cannot stand alone
+func isThaiCombining(r rune) bool {
+	return r == 0x0E31 || (r >= 0x0E34 && r <= 0x0E3A) || (r >= 0x0E47 && r <= 0x0E4E)
+}
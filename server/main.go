@@ -1,8 +1,8 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	// jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/minio/minio-go/v7"
 	"gorm.io/driver/postgres"
@@ -12,8 +12,17 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bestchayapol/DishDive/internal/auth"
 	"github.com/bestchayapol/DishDive/internal/entities"
+	"github.com/bestchayapol/DishDive/internal/events"
 	"github.com/bestchayapol/DishDive/internal/handler"
+	"github.com/bestchayapol/DishDive/internal/jobs"
+	"github.com/bestchayapol/DishDive/internal/llm"
+	"github.com/bestchayapol/DishDive/internal/middleware"
+	"github.com/bestchayapol/DishDive/internal/migrate"
+	"github.com/bestchayapol/DishDive/internal/normalize"
+	"github.com/bestchayapol/DishDive/internal/notify"
+	"github.com/bestchayapol/DishDive/internal/ratelimit"
 	"github.com/bestchayapol/DishDive/internal/repository"
 	"github.com/bestchayapol/DishDive/internal/service"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -47,18 +56,30 @@ func main() {
 			Colorful:                  true,
 		},
 	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger})
+	// TranslateError maps unique violations to gorm.ErrDuplicatedKey
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger, TranslateError: true})
 	if err != nil {
 		panic("❌ Failed to connect to database: " + err.Error())
 	}
 
+	// Data fixes that must happen before AutoMigrate adds constraints
+	if err := migrate.DedupeUsernames(db); err != nil {
+		panic("❌ Failed to dedupe usernames: " + err.Error())
+	}
+	if err := migrate.AddReviewDishSourceType(db); err != nil {
+		panic("❌ Failed to add review_dishes.source_type: " + err.Error())
+	}
+
 	// AutoMigrate all entities
 	err = db.AutoMigrate(
 		&entities.User{},
+		&entities.RefreshToken{},
+		&entities.PasswordResetToken{},
 		&entities.Restaurant{},
 		&entities.RestaurantLocation{},
 		&entities.Dish{},
 		&entities.DishAlias{},
+		&entities.DishCandidate{},
 		&entities.Keyword{},
 		&entities.KeywordAlias{},
 		&entities.Favorite{},
@@ -70,11 +91,41 @@ func main() {
 		&entities.WebReview{},
 		&entities.ReviewExtract{},
 		&entities.CuisineImage{},
+		&entities.RestaurantWhitelistEntry{},
+		&entities.AdminAuditLog{},
+		&entities.RateLimitBucket{},
+		&entities.LoginFailure{},
+		&entities.ReviewJob{},
+		&entities.LLMCacheEntry{},
+		&entities.LLMCall{},
+		&entities.DataMigration{},
 	)
 	if err != nil {
 		panic("❌ Failed to AutoMigrate entities: " + err.Error())
 	}
 
+	categoriesPath := viper.GetString("normalize.categories")
+	categories, err := normalize.LoadCategories(categoriesPath)
+	if err != nil {
+		panic("❌ Failed to load keyword categories: " + err.Error())
+	}
+	normalize.UseCategories(categories)
+	log.Printf("✅ Keyword categories loaded from %s", categories.Source)
+	go normalize.WatchCategories(context.Background(), categoriesPath, viper.GetDuration("normalize.categoriesReload"))
+
+	if err := migrate.MarkCurrentExtracts(db); err != nil {
+		panic("❌ Failed to mark current review extracts: " + err.Error())
+	}
+	if err := migrate.RunOnce(db, "repair_stripped_keywords", migrate.RepairStrippedKeywords); err != nil {
+		panic("❌ Failed to repair stripped keywords: " + err.Error())
+	}
+	if err := migrate.SeedRestaurantWhitelist(db); err != nil {
+		panic("❌ Failed to seed restaurant whitelist: " + err.Error())
+	}
+	if err := migrate.BootstrapAdmins(db, viper.GetStringSlice("admin.bootstrapUsers")); err != nil {
+		panic("❌ Failed to bootstrap admin users: " + err.Error())
+	}
+
 	log.Println("🎉 All migrations completed successfully!")
 
 	minioEndpoint := fmt.Sprintf("%s:%d", viper.GetString("minio.host"), viper.GetInt("minio.port"))
@@ -93,80 +144,188 @@ func main() {
 	userRepositoryDB := repository.NewUserRepositoryDB(db)
 	foodRepositoryDB := repository.NewFoodRepositoryDB(db)
 	recommendRepositoryDB := repository.NewRecommendRepositoryDB(db)
+	adminRepositoryDB := repository.NewAdminRepositoryDB(db)
+	reviewJobRepositoryDB := repository.NewReviewJobRepositoryDB(db)
+	llmUsageRepositoryDB := repository.NewLLMUsageRepositoryDB(db)
+
+	tokenService, err := auth.NewTokenService(auth.Config{
+		Keyset:    loadKeyset(jwtSecret),
+		Issuer:    viper.GetString("jwt.issuer"),
+		Audience:  viper.GetString("jwt.audience"),
+		AccessTTL: viper.GetDuration("jwt.accessTokenTTL"),
+	})
+	if err != nil {
+		panic("❌ Failed to initialise token service: " + err.Error())
+	}
 
-	userService := service.NewUserService(userRepositoryDB, recommendRepositoryDB, jwtSecret)
+	notifier := notify.New(viper.GetString("notify.driver"), viper.GetString("notify.file"))
+	lockout := service.LoginLockout{
+		MaxFailures: viper.GetInt("ratelimit.lockout.maxFailures"),
+		Duration:    viper.GetDuration("ratelimit.lockout.duration"),
+	}
+	userService := service.NewUserService(userRepositoryDB, recommendRepositoryDB, tokenService, viper.GetDuration("jwt.refreshTokenTTL"), notifier, lockout)
 	foodService := service.NewFoodService(foodRepositoryDB, recommendRepositoryDB)
-	recommendService := service.NewRecommendService(foodRepositoryDB, recommendRepositoryDB)
+	llmCache := newLLMCache(db)
+	llmMeter, err := newLLMMeter(llmUsageRepositoryDB)
+	if err != nil {
+		panic("❌ Failed to initialise LLM usage meter: " + err.Error())
+	}
+	eventBus := events.NewBus(viper.GetInt("events.bufferSize"))
+	reviewQueue := jobs.NewReviewQueue(reviewJobRepositoryDB, jobs.Config{
+		Concurrency:  viper.GetInt("jobs.concurrency"),
+		PollInterval: viper.GetDuration("jobs.pollInterval"),
+		MaxAttempts:  viper.GetInt("jobs.maxAttempts"),
+		BackoffBase:  viper.GetDuration("jobs.backoffBase"),
+		BackoffMax:   viper.GetDuration("jobs.backoffMax"),
+		JobTimeout:   viper.GetDuration("jobs.jobTimeout"),
+		StuckAfter:   viper.GetDuration("jobs.stuckAfter"),
+		Gate:         llmMeter,
+	}, eventBus)
+	lexiconPath := viper.GetString("llm.lexicon")
+	lexicon, err := llm.LoadLexicon(lexiconPath)
+	if err != nil {
+		panic("❌ Failed to load extraction lexicon: " + err.Error())
+	}
+	llm.UseLexicon(lexicon)
+	log.Printf("✅ Extraction lexicon loaded from %s", lexicon.Source)
+	go llm.WatchLexicon(context.Background(), lexiconPath, viper.GetDuration("llm.lexiconReload"))
+	go syncDictionaryWords(recommendRepositoryDB, viper.GetDuration("llm.dictionaryRefresh"))
+	extractor, err := newExtractor(llmCache, llmMeter)
+	if err != nil {
+		panic("❌ Failed to configure LLM provider: " + err.Error())
+	}
+	recommendService := service.NewRecommendService(foodRepositoryDB, recommendRepositoryDB, reviewJobRepositoryDB, reviewQueue, eventBus, extractor)
+	if err := reviewQueue.Start(context.Background(), recommendService.ProcessReviewJob); err != nil {
+		panic("❌ Failed to start review job queue: " + err.Error())
+	}
+	adminService := service.NewAdminService(adminRepositoryDB, foodRepositoryDB, recommendRepositoryDB)
 
-	userHandler := handler.NewUserHandler(userService, jwtSecret, uploadService)
+	userHandler := handler.NewUserHandler(userService, uploadService)
 	foodHandler := handler.NewFoodHandler(foodService, recommendService)
-	recommendHandler := handler.NewRecommendHandler(recommendService)
-
-	app := fiber.New()
-
-	// app.Use(func(c *fiber.Ctx) error {
-	// 	if c.Path() != "/Register" && c.Path() != "/Login" {
-	// 		jwtMiddleware := jwtware.New(jwtware.Config{
-	// 			SigningKey: jwtware.SigningKey{Key: []byte(jwtSecret)},
-	// 			ErrorHandler: func(c *fiber.Ctx, err error) error {
-	// 				return fiber.ErrUnauthorized
-	// 			},
-	// 		})
-	// 		return jwtMiddleware(c)
-	// 	}
-	// 	return c.Next()
-	// })
+	recommendHandler := handler.NewRecommendHandler(recommendService, userService)
+	adminHandler := handler.NewAdminHandler(adminService)
+	eventsHandler := handler.NewEventsHandler(eventBus)
+	llmService := service.NewLLMService(llmUsageRepositoryDB, llmMeter, llmCache)
+	llmHandler := handler.NewLLMHandler(llmService)
+	metricsHandler := handler.NewMetricsHandler(llmService, viper.GetString("metrics.token"))
+
+	// app.proxyHeader (e.g. X-Forwarded-For) makes c.IP() use the client address; only set it behind a trusted proxy
+	app := fiber.New(fiber.Config{ErrorHandler: handler.ErrorHandler, ProxyHeader: viper.GetString("app.proxyHeader")})
+	app.Use(middleware.GuardSensitiveFields())
+
+	// Public routes must be registered before the protected group because fiber applies
+	// group middleware (Use) to every route registered after it. requireSameUser is attached
+	// per route since path params are only visible to handlers of the matched route.
+	requireAuth := middleware.RequireAuth(tokenService, userService)
+	requireSameUser := middleware.RequireSameUser()
+	requireAdmin := middleware.RequireRole(userService, entities.RoleAdmin)
+
+	rateStore := newRateLimitStore(db)
+	limitLogin := middleware.RateLimit(rateStore, "login", rateLimitFromConfig("ratelimit.login"))
+	limitLoginUsername := middleware.RateLimitByUsername(rateStore, "login", rateLimitFromConfig("ratelimit.loginUsername"))
+	limitSubmitReview := middleware.RateLimit(rateStore, "submit_review", rateLimitFromConfig("ratelimit.submitReview"))
 
 	//Endpoint ###########################################################################
 
-	// Endpoint for test
-	app.Get("/GetUsers", userHandler.GetUsers)
-	app.Get("/GetUserByUserId/:UserID", userHandler.GetUserByUserId)
-	app.Get("/GetUserByToken", userHandler.GetUserByToken) //#
+	// Public endpoints
+	app.Post("/Register", userHandler.Register)
+	app.Post("/Login", limitLogin, limitLoginUsername, userHandler.Login)
+	app.Post("/RefreshToken", userHandler.RefreshToken)
+	app.Post("/RequestPasswordReset", userHandler.RequestPasswordReset)
+	app.Post("/ResetPassword", userHandler.ResetPassword)
 
-	app.Post("/upload", storageHandler.UploadFile)
+	app.Post("/SearchRestaurantsByDish", foodHandler.SearchRestaurantsByDish)
+	app.Get("/GetRestaurantLocations/:resID", foodHandler.GetRestaurantLocations) // requires ?user_lat=&user_lng=
+	app.Get("/GetDishReviewPage/:dishID", recommendHandler.GetDishReviewPage)
 
-	//////////////////////////////////////////////////////////////////////////////////////
+	// Prometheus scrape endpoint; set metrics.token to require "Authorization: Bearer <token>"
+	app.Get("/metrics", metricsHandler.Metrics)
 
-	// Endpoint for project
-	app.Post("/Register", userHandler.Register)
-	app.Post("/Login", userHandler.Login)
+	// Server-Sent Events stream of the caller's review processing. Registered outside the protected
+	// group because EventSource clients may pass the token as ?access_token= instead of a header.
+	app.Get("/ReviewEvents", middleware.TokenFromQuery("access_token"), requireAuth, eventsHandler.StreamReviewEvents)
 
-	app.Get("/GetCurrentUser", userHandler.GetCurrentUser) //#
-	app.Get("/GetProfileOfCurrentUserByUserId/:UserID", userHandler.GetProfileOfCurrentUserByUserId)
-	app.Get("/GetEditUserProfileByUserId/:UserID", userHandler.GetEditUserProfileByUserId)
-	app.Patch("/PatchEditUserProfileByUserId/:UserID", userHandler.PatchEditUserProfileByUserId)
+	//////////////////////////////////////////////////////////////////////////////////////
 
-	//#####################################################################################
-	// Food endpoints
-	app.Post("/SearchRestaurantsByDish", foodHandler.SearchRestaurantsByDish)
-	app.Get("/GetRestaurantList", foodHandler.GetRestaurantList)
-	app.Get("/GetRestaurantMenu/:resID", foodHandler.GetRestaurantMenu)           // requires ?userID=
-	app.Get("/GetRestaurantLocations/:resID", foodHandler.GetRestaurantLocations) // requires ?user_lat=&user_lng= ; untested// requires ?userID=
-	app.Get("/GetDishDetail/:dishID", foodHandler.GetDishDetail)                  // requires ?userID=
-	app.Get("/GetFavoriteDishes/:userID", foodHandler.GetFavoriteDishes)
-	app.Post("/AddFavorite", foodHandler.AddFavorite)
-	app.Delete("/RemoveFavorite", foodHandler.RemoveFavorite)
-	// app.Post("/AddOrUpdateLocation", foodHandler.AddOrUpdateLocation)
+	// Admin endpoints (admin role required). Registered before the protected group so
+	// requireAuth is not applied twice; every mutation is written to admin_audit_logs.
+	admin := app.Group("/admin", requireAuth, requireAdmin)
+
+	admin.Get("/GetUsers", userHandler.GetUsers)
+	admin.Patch("/SetUserRole/:userID", adminHandler.SetUserRole)
+	admin.Get("/GetAuditLogs", adminHandler.GetAuditLogs) // ?limit=&offset=
+	admin.Get("/GetLLMCacheStats", llmHandler.GetCacheStats)
+	admin.Get("/GetLLMUsage", llmHandler.GetUsage)                                  // ?days=
+	admin.Get("/GetReviewLLMUsage", llmHandler.GetReviewUsage)                      // ?review_id=
+	admin.Get("/GetReviewExtractHistory", recommendHandler.GetReviewExtractHistory) // ?review_id=
+	admin.Get("/DiffReviewExtracts", recommendHandler.DiffReviewExtracts)           // ?review_id=&from=&to=
+	admin.Post("/SetCurrentReviewExtract", adminHandler.SetCurrentReviewExtract)
+
+	admin.Get("/GetRestaurants", adminHandler.GetRestaurants)
+	admin.Post("/AddRestaurant", adminHandler.AddRestaurant)
+	admin.Patch("/UpdateRestaurant/:resID", adminHandler.UpdateRestaurant)
+	admin.Post("/AddOrUpdateLocation", adminHandler.AddOrUpdateLocation)
+	admin.Delete("/DeleteLocation/:rlID", adminHandler.DeleteLocation)
+
+	admin.Get("/GetKeywords", adminHandler.GetKeywords) // optional ?category=
+	admin.Post("/AddKeyword", adminHandler.AddKeyword)
+	admin.Patch("/UpdateKeyword/:keywordID", adminHandler.UpdateKeyword)
+	admin.Post("/RecategorizeKeywords", adminHandler.RecategorizeKeywords) // optional ?dry_run=true
+	admin.Get("/GetDishAliases/:dishID", adminHandler.GetDishAliases)
+	admin.Post("/AddDishAlias", adminHandler.AddDishAlias)
+	admin.Delete("/DeleteDishAlias/:daID", adminHandler.DeleteDishAlias)
+	admin.Get("/GetDishCandidates", adminHandler.GetDishCandidates) // ?status=&res_id=
+	admin.Post("/ResolveDishCandidate", adminHandler.ResolveDishCandidate)
+	admin.Get("/GetKeywordAliases/:keywordID", adminHandler.GetKeywordAliases)
+	admin.Post("/AddKeywordAlias", adminHandler.AddKeywordAlias)
+	admin.Delete("/DeleteKeywordAlias/:kaID", adminHandler.DeleteKeywordAlias)
+
+	admin.Get("/GetWhitelist", adminHandler.GetWhitelist)
+	admin.Post("/AddToWhitelist", adminHandler.AddToWhitelist)
+	admin.Delete("/RemoveFromWhitelist/:wlID", adminHandler.RemoveFromWhitelist)
 
-	//#####################################################################################
-	// Recommend endpoints
-	// New unified settings endpoints
-	app.Get("/GetUserSettings/:userID", recommendHandler.GetUserSettings)
-	app.Post("/UpdateUserSettings/:userID", recommendHandler.UpdateUserSettings)
-	app.Get("/GetUserENGroupStatus/:userID", recommendHandler.GetUserENGroupStatus)
+	//////////////////////////////////////////////////////////////////////////////////////
 
-	// Review and recommendation endpoints
-	app.Get("/GetDishReviewPage/:dishID", recommendHandler.GetDishReviewPage)
-	app.Post("/SubmitReview", recommendHandler.SubmitReview)
-	app.Get("/GetRecommendedDishes/:userID", recommendHandler.GetRecommendedDishes)
+	// Authenticated endpoints (token required, no user ID in the request)
+	protected := app.Group("/", requireAuth)
+
+	protected.Get("/GetUserByToken", userHandler.GetUserByToken)
+	protected.Get("/GetCurrentUser", userHandler.GetCurrentUser)
+	protected.Post("/Logout", userHandler.Logout)
+	protected.Post("/RevokeAllSessions", userHandler.RevokeAllSessions)
+	protected.Post("/ChangePassword", userHandler.ChangePassword)
+	protected.Delete("/DeleteAccount", userHandler.DeleteAccount)
+	protected.Post("/upload", storageHandler.UploadFile)
 
 	// Utilities
-	app.Get("/ReviewExtractStatus", recommendHandler.GetReviewExtractStatus)                // ?review_id=123
-	app.Get("/GetReviewNormalizationStatus", recommendHandler.GetReviewNormalizationStatus) // ?review_id=123
-	app.Get("/GetReviewProcessingStatus", recommendHandler.GetReviewProcessingStatus)       // ?review_id=123
-	app.Get("/EnvStatus", recommendHandler.GetEnvStatus)
-	app.Get("/GetLatestReviewExtract", recommendHandler.GetLatestReviewExtract) // ?review_id=123
+	protected.Get("/ReviewExtractStatus", recommendHandler.GetReviewExtractStatus)                // ?review_id=123
+	protected.Get("/GetReviewNormalizationStatus", recommendHandler.GetReviewNormalizationStatus) // ?review_id=123
+	protected.Get("/GetReviewProcessingStatus", recommendHandler.GetReviewProcessingStatus)       // ?review_id=123
+	protected.Get("/EnvStatus", recommendHandler.GetEnvStatus)
+	protected.Get("/GetLatestReviewExtract", recommendHandler.GetLatestReviewExtract) // ?review_id=123
+
+	//////////////////////////////////////////////////////////////////////////////////////
+
+	// Owner-scoped endpoints: path/query/body user ID must match the token subject
+	protected.Get("/GetUserByUserId/:UserID", requireSameUser, userHandler.GetUserByUserId)
+	protected.Get("/GetProfileOfCurrentUserByUserId/:UserID", requireSameUser, userHandler.GetProfileOfCurrentUserByUserId)
+	protected.Get("/GetEditUserProfileByUserId/:UserID", requireSameUser, userHandler.GetEditUserProfileByUserId)
+	protected.Patch("/PatchEditUserProfileByUserId/:UserID", requireSameUser, userHandler.PatchEditUserProfileByUserId)
+
+	// Food endpoints
+	protected.Get("/GetRestaurantList", requireSameUser, foodHandler.GetRestaurantList)        // optional ?userID=
+	protected.Get("/GetRestaurantMenu/:resID", requireSameUser, foodHandler.GetRestaurantMenu) // requires ?userID=
+	protected.Get("/GetDishDetail/:dishID", requireSameUser, foodHandler.GetDishDetail)        // requires ?userID=
+	protected.Get("/GetFavoriteDishes/:userID", requireSameUser, foodHandler.GetFavoriteDishes)
+	protected.Post("/AddFavorite", requireSameUser, foodHandler.AddFavorite)
+	protected.Delete("/RemoveFavorite", requireSameUser, foodHandler.RemoveFavorite)
+
+	// Recommend endpoints
+	protected.Get("/GetUserSettings/:userID", requireSameUser, recommendHandler.GetUserSettings)
+	protected.Post("/UpdateUserSettings/:userID", requireSameUser, recommendHandler.UpdateUserSettings)
+	protected.Get("/GetUserENGroupStatus/:userID", requireSameUser, recommendHandler.GetUserENGroupStatus)
+	protected.Post("/SubmitReview", requireSameUser, limitSubmitReview, recommendHandler.SubmitReview)
+	protected.Get("/GetRecommendedDishes/:userID", requireSameUser, recommendHandler.GetRecommendedDishes)
 
 	//#####################################################################################
 
@@ -181,6 +340,41 @@ func initConfig() {
 	viper.AddConfigPath(".")        // current directory
 	viper.AddConfigPath("./config") // optional extra path
 
+	viper.SetDefault("jwt.issuer", auth.DefaultIssuer)
+	viper.SetDefault("jwt.audience", auth.DefaultAudience)
+	viper.SetDefault("jwt.accessTokenTTL", "15m")
+	viper.SetDefault("jwt.refreshTokenTTL", "720h")
+	viper.SetDefault("events.bufferSize", 1024)
+	viper.SetDefault("llm.provider", llm.ProviderOpenAI)
+	viper.SetDefault("llm.promptVersion", llm.DefaultPromptVersion)
+	viper.SetDefault("llm.structuredOutput", llm.FormatJSONSchema)
+	viper.SetDefault("llm.lexicon", "") // optional override file; the embedded lexicon by default
+	viper.SetDefault("llm.lexiconReload", "30s")
+	viper.SetDefault("llm.dictionaryRefresh", "10m")
+	viper.SetDefault("normalize.categories", "") // optional override file; the embedded categories by default
+	viper.SetDefault("normalize.categoriesReload", "30s")
+	viper.SetDefault("llm.cache.size", 1000)
+	viper.SetDefault("llm.cache.store", "postgres")
+	viper.SetDefault("llm.pricing", map[string]any{
+		"gpt-4o-mini": map[string]any{"prompt": 0.15, "completion": 0.60},
+	})
+	viper.SetDefault("jobs.concurrency", 2)
+	viper.SetDefault("jobs.pollInterval", "2s")
+	viper.SetDefault("jobs.maxAttempts", 5)
+	viper.SetDefault("jobs.backoffBase", "30s")
+	viper.SetDefault("jobs.backoffMax", "30m")
+	viper.SetDefault("jobs.jobTimeout", "2m")
+	viper.SetDefault("jobs.stuckAfter", "10m")
+	viper.SetDefault("ratelimit.store", "memory")
+	viper.SetDefault("ratelimit.login.burst", 10)
+	viper.SetDefault("ratelimit.login.per", "1m")
+	viper.SetDefault("ratelimit.loginUsername.burst", 20)
+	viper.SetDefault("ratelimit.loginUsername.per", "15m")
+	viper.SetDefault("ratelimit.submitReview.burst", 20)
+	viper.SetDefault("ratelimit.submitReview.per", "1h")
+	viper.SetDefault("ratelimit.lockout.maxFailures", 5)
+	viper.SetDefault("ratelimit.lockout.duration", "15m")
+
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
@@ -189,11 +383,134 @@ func initConfig() {
 	}
 
 	secret := viper.GetString("jwt.jwtSecret")
-	if secret == "" {
+	if secret == "" && viper.GetString("jwt.keysetFile") == "" {
 		log.Println("[config] jwt.jwtSecret is EMPTY")
 	}
 }
 
+// loadKeyset prefers the rotating keyset file (jwt.keysetFile) and falls back to jwt.jwtSecret
+func loadKeyset(jwtSecret string) *auth.Keyset {
+	path := viper.GetString("jwt.keysetFile")
+	if path == "" {
+		return auth.SingleKeyKeyset(jwtSecret)
+	}
+	ks, err := auth.LoadKeysetFile(path)
+	if err != nil {
+		panic("❌ Failed to load JWT keyset: " + err.Error())
+	}
+	log.Printf("[config] loaded %d JWT keys from %s (active kid %s)", len(ks.Keys), path, ks.ActiveKeyID)
+	return ks
+}
+
+// newRateLimitStore picks the rate limit backend from ratelimit.store: "memory" (per instance)
+// or "postgres" (shared across replicas)
+func newRateLimitStore(db *gorm.DB) ratelimit.Store {
+	switch strings.ToLower(viper.GetString("ratelimit.store")) {
+	case "postgres":
+		return repository.NewRateLimitStoreDB(db)
+	case "", "memory":
+		return ratelimit.NewMemoryStore()
+	default:
+		log.Printf("[config] unknown ratelimit.store %q, using memory", viper.GetString("ratelimit.store"))
+		return ratelimit.NewMemoryStore()
+	}
+}
+
+// newExtractor builds the review extractor from llm.* config. OPENAI_API_KEY and OPENAI_MODEL
+// are still honoured for the openai provider when llm.apiKey / llm.model are unset.
+func newExtractor(cache *llm.Cache, meter *llm.Meter) (llm.Extractor, error) {
+	cfg := llm.Config{
+		Provider:      viper.GetString("llm.provider"),
+		BaseURL:       viper.GetString("llm.baseURL"),
+		APIKey:        viper.GetString("llm.apiKey"),
+		Model:         viper.GetString("llm.model"),
+		Timeout:       viper.GetDuration("llm.timeout"),
+		Fixtures:      viper.GetString("llm.fixtures"),
+		PromptVersion: viper.GetString("llm.promptVersion"),
+		PromptDir:     viper.GetString("llm.promptDir"),
+
+		ReaskOnInvalid: viper.GetBool("llm.reaskOnInvalid"),
+		ResponseFormat: viper.GetString("llm.structuredOutput"),
+		Resilience: llm.ResilienceConfig{
+			MaxRetries:       viper.GetInt("llm.maxRetries"),
+			RetryBase:        viper.GetDuration("llm.retryBase"),
+			RetryMax:         viper.GetDuration("llm.retryMax"),
+			MaxConcurrent:    viper.GetInt("llm.maxConcurrent"),
+			BreakerThreshold: viper.GetInt("llm.breakerThreshold"),
+			BreakerCooldown:  viper.GetDuration("llm.breakerCooldown"),
+		},
+		Cache: cache,
+		Meter: meter,
+	}
+	if strings.EqualFold(cfg.Provider, llm.ProviderOpenAI) {
+		if cfg.APIKey == "" {
+			cfg.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+		if cfg.Model == "" {
+			cfg.Model = os.Getenv("OPENAI_MODEL")
+		}
+	}
+	extractor, err := llm.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[config] LLM provider %s, prompt %s", cfg.Provider, extractor.Prompt().CacheID())
+	return extractor, nil
+}
+
+// syncDictionaryWords loads dish and keyword names, and the keyword category terms, into the
+// Thai segmentation dictionary now and then every interval, so dishes added at runtime are
+// recognised (interval <= 0 loads once)
+func syncDictionaryWords(repo repository.RecommendRepository, interval time.Duration) {
+	for {
+		if words, err := repo.FetchDictionaryWords(); err != nil {
+			log.Printf("⚠️ Could not load segmentation words: %v", err)
+		} else {
+			llm.SetDictionaryWords(append(words, normalize.CurrentCategories().Terms()...))
+		}
+		if interval <= 0 {
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// newLLMCache builds the extraction cache from llm.cache.*: size is the in-memory LRU capacity
+// and store is "postgres" (default) or "memory". A negative size disables caching.
+func newLLMCache(db *gorm.DB) *llm.Cache {
+	size := viper.GetInt("llm.cache.size")
+	if size < 0 {
+		return nil
+	}
+	switch strings.ToLower(viper.GetString("llm.cache.store")) {
+	case "", "postgres":
+		return llm.NewCache(size, repository.NewLLMCacheStoreDB(db))
+	case "memory":
+		return llm.NewCache(size, nil)
+	default:
+		log.Printf("[config] unknown llm.cache.store %q, using memory", viper.GetString("llm.cache.store"))
+		return llm.NewCache(size, nil)
+	}
+}
+
+// newLLMMeter prices calls from llm.pricing (USD per million tokens, per model) and pauses the
+// review queue once llm.dailyBudgetUSD is spent
+func newLLMMeter(recorder llm.UsageRecorder) (*llm.Meter, error) {
+	var pricing map[string]llm.Price
+	if err := viper.UnmarshalKey("llm.pricing", &pricing); err != nil {
+		return nil, fmt.Errorf("llm.pricing: %w", err)
+	}
+	return llm.NewMeter(llm.MeterConfig{Pricing: pricing, DailyBudgetUSD: viper.GetFloat64("llm.dailyBudgetUSD")}, recorder)
+}
+
+// rateLimitFromConfig reads <prefix>.burst and <prefix>.per; a zero burst disables the limit
+func rateLimitFromConfig(prefix string) ratelimit.Limit {
+	return ratelimit.Limit{
+		Burst: viper.GetInt(prefix + ".burst"),
+		Per:   viper.GetDuration(prefix + ".per"),
+	}
+}
+
 func initTimeZone() {
 	ict, err := time.LoadLocation("Asia/Bangkok")
 	if err != nil {